@@ -0,0 +1,173 @@
+package sqlparser
+
+import (
+	"fmt"
+)
+
+// FilterAggregate applies a SELECT query with GROUP BY and/or aggregate functions to a map of data.
+// Rows matching the WHERE clause are grouped by the GROUP BY fields (all rows form a single group when
+// there is no GROUP BY), the HAVING clause is evaluated against each group, and one row per group is
+// returned, sorted by ORDER BY and paged by LIMIT and OFFSET.
+// Result rows hold the SELECTed fields and aggregates, named by their alias if any and otherwise by
+// their SQL text, e.g. "COUNT(*)" or "AVG(temp)".
+func FilterAggregate(sql string, data map[string]map[string]any) ([]map[string]any, error) {
+	q, err := Parse(sql)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SQL: %w", err)
+	}
+
+	if q.Type != Select {
+		return nil, fmt.Errorf("only SELECT queries can be aggregated")
+	}
+	if len(q.GroupBy) == 0 && len(q.Aggregates) == 0 {
+		return nil, fmt.Errorf("query has neither GROUP BY nor aggregate functions")
+	}
+	program, err := CompileQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	return program.aggregate(program.filterRows(data)), nil
+}
+
+// aggregate groups the rows matching the WHERE clause of a SELECT query with GROUP BY and/or
+// aggregate functions, and returns one projected row per group
+func (p *Program) aggregate(rows []map[string]any) []map[string]any {
+	q := p.query
+	groups := map[string]*group{}
+	order := []*group{}
+	for _, row := range rows {
+		groupValues := make([]any, len(q.GroupBy))
+		for i, field := range q.GroupBy {
+			groupValues[i], _ = getFieldValue(row, field)
+		}
+		groupKey := fmt.Sprintf("%#v", groupValues)
+		g, ok := groups[groupKey]
+		if !ok {
+			g = newGroup(groupValues, q.Aggregates)
+			groups[groupKey] = g
+			order = append(order, g)
+		}
+		g.add(row)
+	}
+	// Without GROUP BY, aggregates over no rows still produce a single row, e.g. COUNT(*) = 0
+	if len(q.GroupBy) == 0 && len(order) == 0 {
+		order = append(order, newGroup(nil, q.Aggregates))
+	}
+
+	rows = []map[string]any{}
+	for _, g := range order {
+		row := g.row(q, p.fields)
+		if p.having.matches(row) {
+			rows = append(rows, row)
+		}
+	}
+
+	// Group rows already hold the computed fields
+	sortRows(rows, q.OrderBy, nil)
+	rows = pageRows(rows, q)
+
+	result := make([]map[string]any, len(rows))
+	for i, row := range rows {
+		result[i] = projectRow(row, q, nil)
+	}
+	return result
+}
+
+// group accumulates the aggregates of the rows sharing the same GROUP BY values
+type group struct {
+	values       []any
+	accumulators []*accumulator
+}
+
+func newGroup(values []any, aggregates []Aggregate) *group {
+	g := &group{values: values}
+	for _, aggregate := range aggregates {
+		g.accumulators = append(g.accumulators, &accumulator{aggregate: aggregate})
+	}
+	return g
+}
+
+func (g *group) add(row map[string]any) {
+	for _, a := range g.accumulators {
+		a.add(row)
+	}
+}
+
+// row returns the grouped fields, the aggregate results, the computed fields and their aliases as a
+// single row, so that HAVING and ORDER BY can refer to any of them
+func (g *group) row(q Query, fields map[string]valueFunc) map[string]any {
+	row := map[string]any{}
+	for i, field := range q.GroupBy {
+		row[field] = g.values[i]
+	}
+	for _, a := range g.accumulators {
+		row[a.aggregate.String()] = a.result()
+	}
+	for field, value := range fields {
+		row[field] = value.operand(row)
+	}
+	for field, alias := range q.Aliases {
+		if value, ok := row[field]; ok {
+			row[alias] = value
+		}
+	}
+	return row
+}
+
+// accumulator computes a single aggregate over the rows of a group. Missing and nil values are
+// ignored, as are non numeric values for SUM and AVG
+type accumulator struct {
+	aggregate Aggregate
+	count     int
+	sum       float64
+	numbers   int
+	extreme   any
+}
+
+func (a *accumulator) add(row map[string]any) {
+	if a.aggregate.Field == "*" {
+		a.count++
+		return
+	}
+	value, exists := getFieldValue(row, a.aggregate.Field)
+	if !exists || value == nil {
+		return
+	}
+	a.count++
+	if number, ok := toFloat64(value); ok {
+		a.sum += number
+		a.numbers++
+	}
+	switch a.aggregate.Function {
+	case Min:
+		if a.count == 1 || compareSortValues(value, true, a.extreme, true) < 0 {
+			a.extreme = value
+		}
+	case Max:
+		if a.count == 1 || compareSortValues(value, true, a.extreme, true) > 0 {
+			a.extreme = value
+		}
+	}
+}
+
+// result returns the aggregate value. SUM, AVG, MIN and MAX of no values are nil, like SQL NULL
+func (a *accumulator) result() any {
+	switch a.aggregate.Function {
+	case Count:
+		return a.count
+	case Sum:
+		if a.numbers == 0 {
+			return nil
+		}
+		return a.sum
+	case Avg:
+		if a.numbers == 0 {
+			return nil
+		}
+		return a.sum / float64(a.numbers)
+	case Min, Max:
+		return a.extreme
+	default:
+		return nil
+	}
+}
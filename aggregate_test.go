@@ -0,0 +1,92 @@
+package sqlparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterAggregate(t *testing.T) {
+	data := map[string]map[string]any{
+		"1": {"device": "a", "temp": 20, "ts": "100", "bytes": "10"},
+		"2": {"device": "a", "temp": 30, "ts": "300", "bytes": "20"},
+		"3": {"device": "b", "temp": 25.5, "ts": "200", "bytes": "5"},
+		"4": {"device": "a", "temp": nil, "ts": "250"},
+		"5": {"device": "c", "temp": "abc", "ts": "50"},
+	}
+
+	tests := []struct {
+		name        string
+		sql         string
+		expected    []map[string]any
+		expectedErr string
+	}{
+		{
+			name: "GROUP BY with every aggregate function",
+			sql:  "SELECT device, COUNT(*), COUNT(temp), AVG(temp), MIN(ts), MAX(ts), SUM(bytes) FROM t GROUP BY device",
+			expected: []map[string]any{
+				{"device": "a", "COUNT(*)": 3, "COUNT(temp)": 2, "AVG(temp)": 25.0, "MIN(ts)": "100", "MAX(ts)": "300", "SUM(bytes)": 30.0},
+				{"device": "b", "COUNT(*)": 1, "COUNT(temp)": 1, "AVG(temp)": 25.5, "MIN(ts)": "200", "MAX(ts)": "200", "SUM(bytes)": 5.0},
+				{"device": "c", "COUNT(*)": 1, "COUNT(temp)": 1, "AVG(temp)": nil, "MIN(ts)": "50", "MAX(ts)": "50", "SUM(bytes)": nil},
+			},
+		},
+		{
+			name: "HAVING, aliases and ORDER BY an aggregate",
+			sql:  "SELECT device AS d, COUNT(*) AS n FROM t WHERE ts > 60 GROUP BY device HAVING COUNT(*) >= 1 ORDER BY COUNT(*) DESC, d",
+			expected: []map[string]any{
+				{"d": "a", "n": 3},
+				{"d": "b", "n": 1},
+			},
+		},
+		{
+			name: "HAVING filters groups",
+			sql:  "SELECT device FROM t GROUP BY device HAVING COUNT(*) > 1",
+			expected: []map[string]any{
+				{"device": "a"},
+			},
+		},
+		{
+			name: "aggregates without GROUP BY form a single group",
+			sql:  "SELECT COUNT(*), MAX(temp) FROM t WHERE device != 'c'",
+			expected: []map[string]any{
+				{"COUNT(*)": 4, "MAX(temp)": 30},
+			},
+		},
+		{
+			name: "aggregates over no rows",
+			sql:  "SELECT COUNT(*), SUM(bytes) FROM t WHERE device = 'z'",
+			expected: []map[string]any{
+				{"COUNT(*)": 0, "SUM(bytes)": nil},
+			},
+		},
+		{
+			name:     "GROUP BY over no rows",
+			sql:      "SELECT device, COUNT(*) FROM t WHERE device = 'z' GROUP BY device",
+			expected: []map[string]any{},
+		},
+		{
+			name: "LIMIT and OFFSET on groups",
+			sql:  "SELECT device FROM t GROUP BY device ORDER BY device DESC LIMIT 1 OFFSET 1",
+			expected: []map[string]any{
+				{"device": "b"},
+			},
+		},
+		{
+			name:        "query without aggregation fails",
+			sql:         "SELECT device FROM t",
+			expectedErr: "query has neither GROUP BY nor aggregate functions",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := FilterAggregate(tt.sql, data)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, result)
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package sqlparser
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CastType is the type a CastExpr converts to
+type CastType int
+
+const (
+	// UnknownCastType is the zero value for a CastType
+	UnknownCastType CastType = iota
+	// IntType -> "INT"
+	IntType
+	// FloatType -> "FLOAT"
+	FloatType
+	// TextType -> "TEXT"
+	TextType
+	// BoolType -> "BOOL"
+	BoolType
+	// TimestampType -> "TIMESTAMP"
+	TimestampType
+)
+
+// CastTypeString is a string slice with the names of all cast types in order
+var CastTypeString = []string{
+	"UnknownCastType",
+	"IntType",
+	"FloatType",
+	"TextType",
+	"BoolType",
+	"TimestampType",
+}
+
+func (t CastType) String() string {
+	switch t {
+	case IntType:
+		return "INT"
+	case FloatType:
+		return "FLOAT"
+	case TextType:
+		return "TEXT"
+	case BoolType:
+		return "BOOL"
+	case TimestampType:
+		return "TIMESTAMP"
+	default:
+		return "UnknownCastType"
+	}
+}
+
+// castTypes maps the type names accepted by CAST and :: to cast types
+var castTypes = map[string]CastType{
+	"INT": IntType, "INTEGER": IntType, "BIGINT": IntType,
+	"FLOAT": FloatType, "REAL": FloatType, "DOUBLE": FloatType,
+	"TEXT": TextType, "VARCHAR": TextType, "STRING": TextType,
+	"BOOL": BoolType, "BOOLEAN": BoolType,
+	"TIMESTAMP": TimestampType,
+}
+
+// timestampLayouts are the layouts of the strings CAST converts to TIMESTAMP, tried in order. Times
+// without a zone are in UTC.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// castValue converts a value to a type, failing when it doesn't represent a value of the type. NULL
+// stays NULL.
+//
+// Floats convert to INT by truncating toward zero, and strings to numbers once surrounding spaces are
+// trimmed. Numbers convert to BOOL as true unless zero, and strings as strconv.ParseBool reads them.
+// TIMESTAMP values are RFC 3339 strings in UTC to the second, like NOW(), so that they compare in time
+// order. They are converted from the strings of timestampLayouts and from numbers of Unix seconds.
+func castValue(value Value, t CastType) (Value, error) {
+	if value.Kind == NullValue {
+		return value, nil
+	}
+	converted, ok := convertValue(value, t)
+	if !ok {
+		return Value{}, fmt.Errorf("cannot cast %s to %s", value, t)
+	}
+	return converted, nil
+}
+
+func convertValue(value Value, t CastType) (Value, bool) {
+	if value.Kind == StringValue && t != TextType {
+		value.Str = strings.TrimSpace(value.Str)
+	}
+	switch t {
+	case IntType:
+		switch value.Kind {
+		case IntValue:
+			return value, true
+		case BoolValue:
+			return NewInt(int64(boolIndex(value.Bool))), true
+		case StringValue:
+			if i, err := strconv.ParseInt(value.Str, 10, 64); err == nil {
+				return NewInt(i), true
+			}
+		}
+		number, ok := convertValue(value, FloatType)
+		// float64(math.MaxInt64) rounds up to 2^63, which doesn't fit
+		if !ok || math.IsNaN(number.Float) || number.Float < math.MinInt64 || number.Float >= math.MaxInt64 {
+			return Value{}, false
+		}
+		return NewInt(int64(number.Float)), true
+	case FloatType:
+		switch value.Kind {
+		case IntValue:
+			return NewFloat(float64(value.Int)), true
+		case FloatValue:
+			return value, true
+		case BoolValue:
+			return NewFloat(float64(boolIndex(value.Bool))), true
+		case StringValue:
+			f, err := strconv.ParseFloat(value.Str, 64)
+			return NewFloat(f), err == nil
+		}
+	case TextType:
+		return NewString(value.text()), true
+	case BoolType:
+		switch value.Kind {
+		case BoolValue:
+			return value, true
+		case IntValue:
+			return NewBool(value.Int != 0), true
+		case FloatValue:
+			return NewBool(value.Float != 0), true
+		case StringValue:
+			b, err := strconv.ParseBool(value.Str)
+			return NewBool(b), err == nil
+		}
+	case TimestampType:
+		return convertTimestamp(value)
+	}
+	return Value{}, false
+}
+
+func convertTimestamp(value Value) (Value, bool) {
+	var t time.Time
+	switch value.Kind {
+	case IntValue:
+		t = time.Unix(value.Int, 0)
+	case FloatValue:
+		if math.IsNaN(value.Float) || math.IsInf(value.Float, 0) {
+			return Value{}, false
+		}
+		seconds, fraction := math.Modf(value.Float)
+		t = time.Unix(int64(seconds), int64(fraction*1e9))
+	case StringValue:
+		parsed := false
+		for _, layout := range timestampLayouts {
+			var err error
+			if t, err = time.Parse(layout, value.Str); err == nil {
+				parsed = true
+				break
+			}
+		}
+		if !parsed {
+			return Value{}, false
+		}
+	default:
+		return Value{}, false
+	}
+	return NewString(t.UTC().Format(time.RFC3339)), true
+}
+
+// sameType reports whether a row value has the type of a literal kind, integers and floats being the
+// same type. Strict queries only compare values of the same type.
+func sameType(value any, kind ValueKind) bool {
+	valueKind := valueOf(value).Kind
+	isNumber := func(k ValueKind) bool { return k == IntValue || k == FloatValue }
+	return valueKind == kind || isNumber(valueKind) && isNumber(kind)
+}
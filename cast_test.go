@@ -0,0 +1,58 @@
+package sqlparser
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCastValue(t *testing.T) {
+	tests := []struct {
+		value       Value
+		to          CastType
+		expected    Value
+		expectedErr string
+	}{
+		{value: NewString(" 42 "), to: IntType, expected: NewInt(42)},
+		{value: NewString("-1.9"), to: IntType, expected: NewInt(-1)},
+		{value: NewFloat(2.99), to: IntType, expected: NewInt(2)},
+		{value: NewBool(true), to: IntType, expected: NewInt(1)},
+		{value: NewString("9223372036854775807"), to: IntType, expected: NewInt(math.MaxInt64)},
+		{value: NewFloat(1e19), to: IntType, expectedErr: "cannot cast 1e+19 to INT"},
+		{value: NewString("ten"), to: IntType, expectedErr: "cannot cast 'ten' to INT"},
+		{value: NewString("1e3"), to: FloatType, expected: NewFloat(1000)},
+		{value: NewInt(3), to: FloatType, expected: NewFloat(3)},
+		{value: NewBool(false), to: FloatType, expected: NewFloat(0)},
+		{value: NewString(""), to: FloatType, expectedErr: "cannot cast '' to FLOAT"},
+		{value: NewFloat(0.5), to: TextType, expected: NewString("0.5")},
+		{value: NewBool(true), to: TextType, expected: NewString("true")},
+		{value: NewString(" a "), to: TextType, expected: NewString(" a ")},
+		{value: NewString("T"), to: BoolType, expected: NewBool(true)},
+		{value: NewString(" false"), to: BoolType, expected: NewBool(false)},
+		{value: NewInt(-2), to: BoolType, expected: NewBool(true)},
+		{value: NewFloat(0), to: BoolType, expected: NewBool(false)},
+		{value: NewString("yes"), to: BoolType, expectedErr: "cannot cast 'yes' to BOOL"},
+		{value: NewString("2024-03-01T12:30:00+02:00"), to: TimestampType, expected: NewString("2024-03-01T10:30:00Z")},
+		{value: NewString("2024-03-01T12:30:00.75Z"), to: TimestampType, expected: NewString("2024-03-01T12:30:00Z")},
+		{value: NewString("2024-03-01 12:30:00"), to: TimestampType, expected: NewString("2024-03-01T12:30:00Z")},
+		{value: NewString("2024-03-01"), to: TimestampType, expected: NewString("2024-03-01T00:00:00Z")},
+		{value: NewInt(1709296200), to: TimestampType, expected: NewString("2024-03-01T12:30:00Z")},
+		{value: NewFloat(1709296200.9), to: TimestampType, expected: NewString("2024-03-01T12:30:00Z")},
+		{value: NewString("01/03/2024"), to: TimestampType, expectedErr: "cannot cast '01/03/2024' to TIMESTAMP"},
+		{value: NewBool(true), to: TimestampType, expectedErr: "cannot cast TRUE to TIMESTAMP"},
+		{value: Value{}, to: IntType, expected: Value{}},
+		{value: Value{}, to: TimestampType, expected: Value{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value.String()+" AS "+tt.to.String(), func(t *testing.T) {
+			value, err := castValue(tt.value, tt.to)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, value)
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package sqlparser
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSVOptions configures how CSV files are read and written
+type CSVOptions struct {
+	// Comma is the field delimiter, ',' when zero
+	Comma rune
+	// Comment is the character starting lines to ignore, none when zero
+	Comment rune
+	// LazyQuotes allows quotes in unquoted fields and non doubled quotes in quoted fields
+	LazyQuotes bool
+	// TrimLeadingSpace ignores leading white space in fields
+	TrimLeadingSpace bool
+	// NoHeader means the first record holds data rather than column names. Columns are then
+	// named by Columns, or col1, col2, ... in order
+	NoHeader bool
+	// Columns names the columns, overriding the header row if there is one
+	Columns []string
+	// UseCRLF ends written lines with \r\n instead of \n
+	UseCRLF bool
+	// Strict runs queries with Query.Strict set: fields are then strings that only compare with
+	// strings, unless converted with CAST
+	Strict bool
+}
+
+// CSVEngine runs queries against CSV files. The table name of a query is the path of a file
+// relative to Root, e.g. SELECT * FROM 'logs/data.csv'. Every value read from a file is a string.
+type CSVEngine struct {
+	Root    string
+	Options CSVOptions
+	// LockFile serializes Exec calls on a table with a "<file>.lock" file, across goroutines and
+	// processes. A lock file left behind by a crashed writer must be removed by hand.
+	LockFile bool
+	// LockTimeout is how long Exec waits for the lock file, 10 seconds when zero
+	LockTimeout time.Duration
+}
+
+// NewCSVEngine returns an engine reading the CSV files under root
+func NewCSVEngine(root string, options CSVOptions) *CSVEngine {
+	return &CSVEngine{Root: root, Options: options}
+}
+
+// Path returns the path of the file holding a table. It fails for names leading outside of Root.
+func (e *CSVEngine) Path(tableName string) (string, error) {
+	name := filepath.Clean(filepath.FromSlash(tableName))
+	if tableName == "" || filepath.IsAbs(name) || name == ".." ||
+		strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("table %s is outside of the root directory", tableName)
+	}
+	return filepath.Join(e.Root, name), nil
+}
+
+// Query runs a SELECT query against a CSV file and returns its result set, like FilterProjected.
+// Records are streamed through the WHERE clause so that only matching rows are kept in memory, and
+// reading stops as soon as LIMIT is reached when the query neither sorts nor groups.
+func (e *CSVEngine) Query(sql string) ([]map[string]any, error) {
+	q, err := Parse(sql)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SQL: %w", err)
+	}
+	rows, _, err := e.query(q)
+	return rows, err
+}
+
+// QueryCSV runs a SELECT query against a CSV file and writes its result set to w as CSV, with a
+// header row naming the result columns. Missing and nil values are written as empty fields.
+func (e *CSVEngine) QueryCSV(w io.Writer, sql string) error {
+	q, err := Parse(sql)
+	if err != nil {
+		return fmt.Errorf("failed to parse SQL: %w", err)
+	}
+	rows, columns, err := e.query(q)
+	if err != nil {
+		return err
+	}
+	return e.write(w, resultColumns(q, columns), rows)
+}
+
+func (e *CSVEngine) query(q Query) ([]map[string]any, []string, error) {
+	if q.Type != Select {
+		return nil, nil, fmt.Errorf("only SELECT queries can be run against CSV files")
+	}
+	q.Strict = e.Options.Strict
+	streaming := q.HasLimit && len(q.OrderBy) == 0 && len(q.GroupBy) == 0 && len(q.Aggregates) == 0
+	program, err := CompileQuery(q)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows := []map[string]any{}
+	columns, err := e.scan(q.TableName, func(_ []string, row map[string]any) bool {
+		if !program.where.matches(row) {
+			return true
+		}
+		rows = append(rows, row)
+		return !streaming || len(rows) < q.Offset+q.Limit
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return program.selectRows(rows), columns, nil
+}
+
+// scan reads the records of a table file and passes them to fn, along with the rows they hold,
+// until it returns false. It returns the column names.
+func (e *CSVEngine) scan(tableName string, fn func(record []string, row map[string]any) bool) ([]string, error) {
+	path, err := e.Path(tableName)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open table %s: %w", tableName, err)
+	}
+	defer f.Close()
+
+	r := e.reader(f)
+	columns, first, err := e.readColumns(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read table %s: %w", tableName, err)
+	}
+	record := first
+	for {
+		if record == nil {
+			record, err = r.Read()
+			if errors.Is(err, io.EOF) {
+				return columns, nil
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read table %s: %w", tableName, err)
+			}
+		}
+		row := make(map[string]any, len(columns))
+		for i, value := range record {
+			if i < len(columns) {
+				row[columns[i]] = value
+			}
+		}
+		if !fn(record, row) {
+			return columns, nil
+		}
+		record = nil
+	}
+}
+
+// readColumns returns the column names of a file. Without a header row, it also returns the first
+// record, which holds data.
+func (e *CSVEngine) readColumns(r *csv.Reader) ([]string, []string, error) {
+	first, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return e.Options.Columns, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	columns := first
+	if e.Options.NoHeader {
+		columns = make([]string, len(first))
+		for i := range columns {
+			columns[i] = "col" + strconv.Itoa(i+1)
+		}
+	} else {
+		first = nil
+	}
+	if e.Options.Columns != nil {
+		columns = e.Options.Columns
+	}
+
+	seen := map[string]bool{}
+	for _, column := range columns {
+		if seen[column] {
+			return nil, nil, fmt.Errorf("duplicate column %s", column)
+		}
+		seen[column] = true
+	}
+	return columns, first, nil
+}
+
+func (e *CSVEngine) reader(f io.Reader) *csv.Reader {
+	r := csv.NewReader(f)
+	if e.Options.Comma != 0 {
+		r.Comma = e.Options.Comma
+	}
+	r.Comment = e.Options.Comment
+	r.LazyQuotes = e.Options.LazyQuotes
+	r.TrimLeadingSpace = e.Options.TrimLeadingSpace
+	return r
+}
+
+// write writes rows as CSV records holding the given columns, after a header row
+func (e *CSVEngine) write(w io.Writer, columns []string, rows []map[string]any) error {
+	cw := csv.NewWriter(w)
+	if e.Options.Comma != 0 {
+		cw.Comma = e.Options.Comma
+	}
+	cw.UseCRLF = e.Options.UseCRLF
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	record := make([]string, len(columns))
+	for _, row := range rows {
+		for i, column := range columns {
+			record[i] = ""
+			if value, ok := row[column]; ok && value != nil {
+				record[i] = fmt.Sprintf("%v", value)
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// resultColumns returns the column names of the result set of a SELECT query, in SELECT order.
+// "*" stands for the columns of the table.
+func resultColumns(q Query, columns []string) []string {
+	result := []string{}
+	seen := map[string]bool{}
+	for _, field := range q.Fields {
+		names := []string{field}
+		if field == "*" {
+			names = columns
+		} else if alias, ok := q.Aliases[field]; ok {
+			names = []string{alias}
+		}
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				result = append(result, name)
+			}
+		}
+	}
+	return result
+}
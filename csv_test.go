@@ -0,0 +1,168 @@
+package sqlparser
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestCSVEngineQuery(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "data.csv", "device,temp,note\n"+
+		"a,20,\"hello, world\"\n"+
+		"b,35,\"multi\nline\"\n"+
+		"a,30,plain\n"+
+		"c,5,\"say \"\"hi\"\"\"\n")
+	writeFile(t, dir, "logs/semicolon.csv", "# exported\ndevice; temp\na; 1\nb; 2\n")
+	writeFile(t, dir, "raw.csv", "a,1\nb,2\n")
+	writeFile(t, dir, "ragged.csv", "device,temp\na,1\nb,2\nc\n")
+
+	engine := NewCSVEngine(dir, CSVOptions{})
+
+	tests := []struct {
+		name        string
+		engine      *CSVEngine
+		sql         string
+		expected    []map[string]any
+		expectedErr string
+	}{
+		{
+			name:   "rows keep file order and quoted fields",
+			engine: engine,
+			sql:    "SELECT * FROM 'data.csv' WHERE device = 'a' OR temp > 30",
+			expected: []map[string]any{
+				{"device": "a", "temp": "20", "note": "hello, world"},
+				{"device": "b", "temp": "35", "note": "multi\nline"},
+				{"device": "a", "temp": "30", "note": "plain"},
+			},
+		},
+		{
+			name:   "projection, ORDER BY and LIMIT",
+			engine: engine,
+			sql:    "SELECT device AS d, note FROM 'data.csv' ORDER BY temp LIMIT 2",
+			expected: []map[string]any{
+				{"d": "c", "note": "say \"hi\""},
+				{"d": "a", "note": "hello, world"},
+			},
+		},
+		{
+			name:   "aggregates",
+			engine: engine,
+			sql:    "SELECT device, COUNT(*), MAX(temp) FROM data.csv GROUP BY device",
+			expected: []map[string]any{
+				{"device": "a", "COUNT(*)": 2, "MAX(temp)": "30"},
+				{"device": "b", "COUNT(*)": 1, "MAX(temp)": "35"},
+				{"device": "c", "COUNT(*)": 1, "MAX(temp)": "5"},
+			},
+		},
+		{
+			name:   "delimiter, comment and leading space options",
+			engine: NewCSVEngine(dir, CSVOptions{Comma: ';', Comment: '#', TrimLeadingSpace: true}),
+			sql:    "SELECT * FROM 'logs/semicolon.csv' WHERE temp > 1",
+			expected: []map[string]any{
+				{"device": "b", "temp": "2"},
+			},
+		},
+		{
+			name:   "no header row",
+			engine: NewCSVEngine(dir, CSVOptions{NoHeader: true}),
+			sql:    "SELECT col1 FROM 'raw.csv'",
+			expected: []map[string]any{
+				{"col1": "a"},
+				{"col1": "b"},
+			},
+		},
+		{
+			name:   "column names given as option",
+			engine: NewCSVEngine(dir, CSVOptions{NoHeader: true, Columns: []string{"device", "temp"}}),
+			sql:    "SELECT device FROM 'raw.csv' WHERE temp = '2'",
+			expected: []map[string]any{
+				{"device": "b"},
+			},
+		},
+		{
+			name:     "strict fields only compare as strings",
+			engine:   NewCSVEngine(dir, CSVOptions{Strict: true}),
+			sql:      "SELECT device FROM 'data.csv' WHERE temp > 30",
+			expected: []map[string]any{},
+		},
+		{
+			name:   "strict fields compare as numbers once converted",
+			engine: NewCSVEngine(dir, CSVOptions{Strict: true}),
+			sql:    "SELECT device, temp::INT * 2 AS double FROM 'data.csv' WHERE CAST(temp AS INT) > 9 ORDER BY double DESC",
+			expected: []map[string]any{
+				{"device": "b", "double": int64(70)},
+				{"device": "a", "double": int64(60)},
+				{"device": "a", "double": int64(40)},
+			},
+		},
+		{
+			name:   "reading stops once LIMIT is reached",
+			engine: engine,
+			sql:    "SELECT device FROM 'ragged.csv' LIMIT 1 OFFSET 1",
+			expected: []map[string]any{
+				{"device": "b"},
+			},
+		},
+		{
+			name:        "malformed records fail",
+			engine:      engine,
+			sql:         "SELECT device FROM 'ragged.csv'",
+			expectedErr: "failed to read table ragged.csv: record on line 4: wrong number of fields",
+		},
+		{
+			name:        "tables outside of the root directory fail",
+			engine:      engine,
+			sql:         "SELECT * FROM '../data.csv'",
+			expectedErr: "table ../data.csv is outside of the root directory",
+		},
+		{
+			name:        "non SELECT queries fail",
+			engine:      engine,
+			sql:         "DELETE FROM 'data.csv' WHERE device = 'a'",
+			expectedErr: "only SELECT queries can be run against CSV files",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.engine.Query(tt.sql)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, result)
+		})
+	}
+
+	_, err := engine.Query("SELECT * FROM 'missing.csv'")
+	require.True(t, errors.Is(err, os.ErrNotExist), err)
+}
+
+func TestCSVEngineQueryCSV(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "data.csv", "device,temp,note\na,20,\"hello, world\"\nb,35,\n")
+	writeFile(t, dir, "data.tsv", "device\ttemp\na\t20\nb\t35\n")
+
+	var buf bytes.Buffer
+	err := NewCSVEngine(dir, CSVOptions{}).QueryCSV(&buf, "SELECT note AS n, * FROM 'data.csv'")
+	require.NoError(t, err)
+	require.Equal(t, "n,device,temp,note\n\"hello, world\",a,20,\"hello, world\"\n,b,35,\n", buf.String())
+
+	buf.Reset()
+	err = NewCSVEngine(dir, CSVOptions{Comma: '\t', UseCRLF: true}).QueryCSV(&buf, "SELECT device, COUNT(*) AS n FROM 'data.tsv' GROUP BY device")
+	require.NoError(t, err)
+	require.Equal(t, "device\tn\r\na\t1\r\nb\t1\r\n", buf.String())
+}
@@ -0,0 +1,200 @@
+package sqlparser
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// defaultLockTimeout is how long Exec waits for the lock file of a table when LockTimeout is zero
+const defaultLockTimeout = 10 * time.Second
+
+// Exec runs an INSERT, UPDATE or DELETE query against a CSV file and returns the number of affected
+// rows. INSERT and UPDATE must only name columns of the file, and INSERT leaves the other columns empty.
+//
+// The file is rewritten atomically: the new content is written to a temporary file in the same
+// directory, synced to disk and renamed over the original, so that readers and crashes only ever see
+// the old or the new file. Comment lines are not preserved. When LockFile is set, writers are
+// serialized with a "<file>.lock" file next to the table.
+func (e *CSVEngine) Exec(sql string) (int, error) {
+	q, err := Parse(sql)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse SQL: %w", err)
+	}
+	if q.Type != Insert && q.Type != Update && q.Type != Delete {
+		return 0, fmt.Errorf("only INSERT, UPDATE and DELETE queries can be executed against CSV files")
+	}
+	q.Strict = e.Options.Strict
+	path, err := e.Path(q.TableName)
+	if err != nil {
+		return 0, err
+	}
+
+	if e.LockFile {
+		unlock, err := lockFile(path+".lock", e.LockTimeout)
+		if err != nil {
+			return 0, fmt.Errorf("failed to lock table %s: %w", q.TableName, err)
+		}
+		defer unlock()
+	}
+
+	where := compileExpr(q.where(), q.Strict)
+	assignments := compileAssignments(q)
+	inserts, err := insertValues(q)
+	if err != nil {
+		return 0, err
+	}
+	records := [][]string{}
+	// matched holds the row of each matching record, to compute the SET values from, and nil otherwise
+	matched := []map[string]any{}
+	columns, err := e.scan(q.TableName, func(record []string, row map[string]any) bool {
+		records = append(records, record)
+		if q.Type == Insert || !where.matches(row) {
+			row = nil
+		}
+		matched = append(matched, row)
+		return true
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	index := map[string]int{}
+	for i, column := range columns {
+		index[column] = i
+	}
+	fields := q.Fields
+	for _, assignment := range q.Assignments {
+		fields = append(fields, assignment.Field)
+	}
+	for _, field := range fields {
+		if _, ok := index[field]; !ok {
+			return 0, fmt.Errorf("unknown column %s in table %s", field, q.TableName)
+		}
+	}
+
+	count := 0
+	result := make([][]string, 0, len(records)+len(inserts))
+	for i, record := range records {
+		if matched[i] == nil {
+			result = append(result, record)
+			continue
+		}
+		count++
+		if q.Type == Update {
+			updated := make([]string, len(columns))
+			copy(updated, record)
+			for j, assignment := range q.Assignments {
+				value, err := assignments[j](matched[i])
+				if err != nil {
+					return 0, fmt.Errorf("at SET %s: %w", assignment.Field, err)
+				}
+				updated[index[assignment.Field]] = value.text()
+			}
+			result = append(result, updated)
+		}
+	}
+	for _, values := range inserts {
+		record := make([]string, len(columns))
+		for i, field := range q.Fields {
+			record[index[field]] = values[i].text()
+		}
+		result = append(result, record)
+		count++
+	}
+
+	if count == 0 {
+		return 0, nil
+	}
+	if err := e.replace(path, columns, result); err != nil {
+		return 0, fmt.Errorf("failed to write table %s: %w", q.TableName, err)
+	}
+	return count, nil
+}
+
+// replace atomically replaces the file at path with the given records, preceded by a header row
+// unless the engine reads files without one
+func (e *CSVEngine) replace(path string, columns []string, records [][]string) (err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	w := csv.NewWriter(tmp)
+	if e.Options.Comma != 0 {
+		w.Comma = e.Options.Comma
+	}
+	w.UseCRLF = e.Options.UseCRLF
+	if !e.Options.NoHeader {
+		if err = w.Write(columns); err != nil {
+			return err
+		}
+	}
+	if err = w.WriteAll(records); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(info.Mode().Perm()); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// syncDir flushes a directory entry change such as a rename to disk. Platforms that can't sync
+// directories are ignored.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil && !errors.Is(err, os.ErrInvalid) && !errors.Is(err, os.ErrPermission) {
+		return err
+	}
+	return nil
+}
+
+// lockFile creates the lock file at path, waiting up to timeout for another writer to remove it.
+// It returns a function removing the lock file.
+func lockFile(path string, timeout time.Duration) (func(), error) {
+	if timeout == 0 {
+		timeout = defaultLockTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for %s", path)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,118 @@
+package sqlparser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVEngineExec(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "data.csv", "device,temp,note\na,20,x\nb,35,\"y, z\"\n")
+	engine := NewCSVEngine(dir, CSVOptions{})
+
+	steps := []struct {
+		sql         string
+		expected    int
+		expectedErr string
+		content     string
+	}{
+		{
+			sql:      "INSERT INTO 'data.csv' (device, temp) VALUES ('c', '5')",
+			expected: 1,
+			content:  "device,temp,note\na,20,x\nb,35,\"y, z\"\nc,5,\n",
+		},
+		{
+			sql:      "UPDATE 'data.csv' SET note = 'hot' WHERE temp > 30",
+			expected: 1,
+			content:  "device,temp,note\na,20,x\nb,35,hot\nc,5,\n",
+		},
+		{
+			sql:      "UPDATE 'data.csv' SET note = 'none' WHERE device = 'missing'",
+			expected: 0,
+			content:  "device,temp,note\na,20,x\nb,35,hot\nc,5,\n",
+		},
+		{
+			sql:      "DELETE FROM 'data.csv' WHERE device = 'a' OR device = 'c'",
+			expected: 2,
+			content:  "device,temp,note\nb,35,hot\n",
+		},
+		{
+			sql:         "UPDATE 'data.csv' SET color = 'red' WHERE device = 'b'",
+			expectedErr: "unknown column color in table data.csv",
+			content:     "device,temp,note\nb,35,hot\n",
+		},
+		{
+			sql:      "UPDATE 'data.csv' SET temp = temp + 1, note = device WHERE device = 'b'",
+			expected: 1,
+			content:  "device,temp,note\nb,36,b\n",
+		},
+		{
+			sql:         "UPDATE 'data.csv' SET temp = note / 2 WHERE device = 'b'",
+			expectedErr: "at SET temp: cannot apply / to 'b'",
+			content:     "device,temp,note\nb,36,b\n",
+		},
+		{
+			sql:         "INSERT INTO 'data.csv' (device, temp) VALUES ('c', 1), ('d', 'x'::INT)",
+			expectedErr: "at INSERT INTO: cannot cast 'x' to INT",
+			content:     "device,temp,note\nb,36,b\n",
+		},
+		{
+			sql:         "SELECT * FROM 'data.csv'",
+			expectedErr: "only INSERT, UPDATE and DELETE queries can be executed against CSV files",
+			content:     "device,temp,note\nb,36,b\n",
+		},
+	}
+	for _, step := range steps {
+		t.Run(step.sql, func(t *testing.T) {
+			count, err := engine.Exec(step.sql)
+			if step.expectedErr != "" {
+				require.EqualError(t, err, step.expectedErr)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, step.expected, count)
+			}
+			content, err := os.ReadFile(filepath.Join(dir, "data.csv"))
+			require.NoError(t, err)
+			require.Equal(t, step.content, string(content))
+		})
+	}
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "temporary files must not be left behind")
+}
+
+func TestCSVEngineExecLockFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "data.csv", "id\n")
+	engine := NewCSVEngine(dir, CSVOptions{})
+	engine.LockFile = true
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := engine.Exec(fmt.Sprintf("INSERT INTO 'data.csv' (id) VALUES ('%d')", i))
+			require.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	rows, err := engine.Query("SELECT id FROM 'data.csv'")
+	require.NoError(t, err)
+	require.Len(t, rows, 20)
+	_, err = os.Stat(filepath.Join(dir, "data.csv.lock"))
+	require.True(t, os.IsNotExist(err))
+
+	writeFile(t, dir, "data.csv.lock", "")
+	engine.LockTimeout = 50 * time.Millisecond
+	_, err = engine.Exec("DELETE FROM 'data.csv' WHERE id = '1'")
+	require.EqualError(t, err, "failed to lock table data.csv: timed out waiting for "+filepath.Join(dir, "data.csv.lock"))
+}
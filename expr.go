@@ -0,0 +1,497 @@
+package sqlparser
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ValueExpr is an expression computing a value, such as the right hand side of an UPDATE assignment:
+// a *Literal, a *FieldRef, a *UnaryExpr, a *BinaryExpr, a *FuncCall, a *CaseExpr or a *CastExpr
+type ValueExpr interface {
+	String() string
+	valueExpr()
+}
+
+// Literal is a literal value such as 'a' or 10
+type Literal struct {
+	Value Value
+}
+
+// FieldRef is the value of a field of the row, nil when it is missing
+type FieldRef struct {
+	Name string
+	// Quoted determines if Name was written as a quoted identifier
+	Quoted bool
+}
+
+// UnaryExpr applies a sign to an expression: Subtract negates it and Add keeps it
+type UnaryExpr struct {
+	Operator ArithmeticOperator
+	Expr     ValueExpr
+}
+
+// BinaryExpr applies an arithmetic or concatenation operator to two expressions
+type BinaryExpr struct {
+	Operator ArithmeticOperator
+	Left     ValueExpr
+	Right    ValueExpr
+}
+
+// FuncCall is a call of a scalar function such as NOW()
+type FuncCall struct {
+	// Name is the function name as written
+	Name string
+	Args []ValueExpr
+}
+
+// CaseExpr is a CASE expression, whose value is the Result of the first matching When, or Else. With an
+// Operand, it is a simple CASE matching the operand with the Value of each When as "=" would; without,
+// it is a searched CASE testing the Condition of each When.
+type CaseExpr struct {
+	Operand ValueExpr
+	Whens   []When
+	// Else is nil without an ELSE, the value of the CASE then being NULL when no When matches
+	Else ValueExpr
+}
+
+// CastExpr converts the value of an expression to a type, written CAST(expr AS type) or expr::type.
+// A value that doesn't convert fails UPDATE and INSERT, and is NULL when filtering and in SELECTed
+// fields, like any expression that can't be computed.
+type CastExpr struct {
+	Expr ValueExpr
+	Type CastType
+}
+
+// When is a WHEN ... THEN ... branch of a CASE expression
+type When struct {
+	// Condition is set in a searched CASE, and Value in a simple CASE
+	Condition Expr
+	Value     ValueExpr
+	Result    ValueExpr
+}
+
+func (*Literal) valueExpr()    {}
+func (*FieldRef) valueExpr()   {}
+func (*UnaryExpr) valueExpr()  {}
+func (*BinaryExpr) valueExpr() {}
+func (*FuncCall) valueExpr()   {}
+func (*CaseExpr) valueExpr()   {}
+func (*CastExpr) valueExpr()   {}
+
+func (e *Literal) String() string {
+	return e.Value.String()
+}
+
+func (e *FieldRef) String() string {
+	return quoteName(e.Name, e.Quoted)
+}
+
+func (e *UnaryExpr) String() string {
+	operand := e.Expr.String()
+	// A second minus sign would start a comment
+	if _, ok := e.Expr.(*BinaryExpr); ok || strings.HasPrefix(operand, "-") || strings.HasPrefix(operand, "+") {
+		operand = "(" + operand + ")"
+	}
+	return e.Operator.String() + operand
+}
+
+func (e *BinaryExpr) String() string {
+	left, right := e.Left.String(), e.Right.String()
+	// Operands binding looser than the operator are parenthesized, and so are right operands binding
+	// as loose since the operators are left associative
+	if operand, ok := e.Left.(*BinaryExpr); ok && operand.Operator.precedence() < e.Operator.precedence() {
+		left = "(" + left + ")"
+	}
+	if operand, ok := e.Right.(*BinaryExpr); ok && operand.Operator.precedence() <= e.Operator.precedence() {
+		right = "(" + right + ")"
+	}
+	return left + " " + e.Operator.String() + " " + right
+}
+
+func (e *FuncCall) String() string {
+	args := make([]string, len(e.Args))
+	for i, arg := range e.Args {
+		args[i] = arg.String()
+	}
+	return e.Name + "(" + strings.Join(args, ", ") + ")"
+}
+
+func (e *CaseExpr) String() string {
+	var sb strings.Builder
+	sb.WriteString("CASE")
+	if e.Operand != nil {
+		sb.WriteString(" ")
+		sb.WriteString(e.Operand.String())
+	}
+	for _, when := range e.Whens {
+		sb.WriteString(" WHEN ")
+		if when.Condition != nil {
+			sb.WriteString(when.Condition.String())
+		} else {
+			sb.WriteString(when.Value.String())
+		}
+		sb.WriteString(" THEN ")
+		sb.WriteString(when.Result.String())
+	}
+	if e.Else != nil {
+		sb.WriteString(" ELSE ")
+		sb.WriteString(e.Else.String())
+	}
+	sb.WriteString(" END")
+	return sb.String()
+}
+
+func (e *CastExpr) String() string {
+	return "CAST(" + e.Expr.String() + " AS " + e.Type.String() + ")"
+}
+
+// ArithmeticOperator is the operator of a BinaryExpr
+type ArithmeticOperator int
+
+const (
+	// UnknownArithmeticOperator is the zero value for an ArithmeticOperator
+	UnknownArithmeticOperator ArithmeticOperator = iota
+	// Add -> "+"
+	Add
+	// Subtract -> "-"
+	Subtract
+	// Multiply -> "*"
+	Multiply
+	// Divide -> "/"
+	Divide
+	// Modulo -> "%"
+	Modulo
+	// Concat -> "||"
+	Concat
+)
+
+// ArithmeticOperatorString is a string slice with the names of all arithmetic operators in order
+var ArithmeticOperatorString = []string{
+	"UnknownArithmeticOperator",
+	"Add",
+	"Subtract",
+	"Multiply",
+	"Divide",
+	"Modulo",
+	"Concat",
+}
+
+func (o ArithmeticOperator) String() string {
+	switch o {
+	case Add:
+		return "+"
+	case Subtract:
+		return "-"
+	case Multiply:
+		return "*"
+	case Divide:
+		return "/"
+	case Modulo:
+		return "%"
+	case Concat:
+		return "||"
+	default:
+		return "UnknownArithmeticOperator"
+	}
+}
+
+// precedence returns how tightly the operator binds its operands: * / and % bind tighter than + and -,
+// which bind tighter than ||
+func (o ArithmeticOperator) precedence() int {
+	switch o {
+	case Multiply, Divide, Modulo:
+		return 3
+	case Add, Subtract:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// arithmeticOperators maps operator tokens to arithmetic operators
+var arithmeticOperators = map[string]ArithmeticOperator{
+	"+": Add, "-": Subtract, "*": Multiply, "/": Divide, "%": Modulo, "||": Concat,
+}
+
+// evaluateValueExpr computes an expression against a row. Arithmetic with NULL, or with a missing
+// field, is NULL.
+func evaluateValueExpr(expr ValueExpr, row map[string]any) (Value, error) {
+	return compileValueExpr(expr, false)(row)
+}
+
+// valueFunc is a compiled ValueExpr
+type valueFunc func(row map[string]any) (Value, error)
+
+// operand returns the value as a condition operand or a result field, nil when it can't be computed
+func (f valueFunc) operand(row map[string]any) any {
+	value, err := f(row)
+	if err != nil {
+		return nil
+	}
+	return value.Any()
+}
+
+// compileValueExpr prepares an expression for computing it against many rows: field paths are split,
+// functions are looked up and the conditions of CASE expressions are compiled once, so that functions
+// registered after compiling are not called. Strict expressions don't take numeric strings as numbers,
+// see Query.Strict.
+func compileValueExpr(expr ValueExpr, strict bool) valueFunc {
+	switch e := expr.(type) {
+	case *Literal:
+		value := e.Value
+		return func(map[string]any) (Value, error) {
+			return value, nil
+		}
+	case *FieldRef:
+		path := newFieldPath(e.Name)
+		return func(row map[string]any) (Value, error) {
+			value, _ := path.get(row)
+			return valueOf(value), nil
+		}
+	case *UnaryExpr:
+		operand := compileValueExpr(e.Expr, strict)
+		return func(row map[string]any) (Value, error) {
+			value, err := operand(row)
+			if err != nil {
+				return Value{}, err
+			}
+			return applySign(e.Operator, value, strict)
+		}
+	case *BinaryExpr:
+		left, right := compileValueExpr(e.Left, strict), compileValueExpr(e.Right, strict)
+		return func(row map[string]any) (Value, error) {
+			l, err := left(row)
+			if err != nil {
+				return Value{}, err
+			}
+			r, err := right(row)
+			if err != nil {
+				return Value{}, err
+			}
+			return applyArithmetic(e.Operator, l, r, strict)
+		}
+	case *FuncCall:
+		function, ok := lookupFunction(e.Name)
+		if !ok {
+			return func(map[string]any) (Value, error) {
+				return Value{}, fmt.Errorf("unknown function %s", strings.ToUpper(e.Name))
+			}
+		}
+		if err := function.checkArity(e.Name, len(e.Args)); err != nil {
+			return func(map[string]any) (Value, error) {
+				return Value{}, err
+			}
+		}
+		args := make([]valueFunc, len(e.Args))
+		for i, arg := range e.Args {
+			args[i] = compileValueExpr(arg, strict)
+		}
+		return func(row map[string]any) (Value, error) {
+			values := make([]Value, len(args))
+			for i, arg := range args {
+				var err error
+				if values[i], err = arg(row); err != nil {
+					return Value{}, err
+				}
+			}
+			return function.impl(values)
+		}
+	case *CaseExpr:
+		return compileCase(e, strict)
+	case *CastExpr:
+		operand := compileValueExpr(e.Expr, strict)
+		return func(row map[string]any) (Value, error) {
+			value, err := operand(row)
+			if err != nil {
+				return Value{}, err
+			}
+			return castValue(value, e.Type)
+		}
+	default:
+		return func(map[string]any) (Value, error) {
+			return Value{}, fmt.Errorf("unknown expression %v", expr)
+		}
+	}
+}
+
+// compileCase compiles a CASE expression. A When matches when its condition is true, or when the
+// operand equals its value: NULL and conditions that can't be computed don't match.
+func compileCase(e *CaseExpr, strict bool) valueFunc {
+	type branch struct {
+		condition *expression
+		value     valueFunc
+		result    valueFunc
+	}
+	var operand valueFunc
+	if e.Operand != nil {
+		operand = compileValueExpr(e.Operand, strict)
+	}
+	branches := make([]branch, len(e.Whens))
+	for i, when := range e.Whens {
+		branches[i].result = compileValueExpr(when.Result, strict)
+		if operand != nil {
+			branches[i].value = compileValueExpr(when.Value, strict)
+		} else {
+			branches[i].condition = compileExpr(when.Condition, strict)
+		}
+	}
+	otherwise := valueFunc(func(map[string]any) (Value, error) {
+		return Value{}, nil
+	})
+	if e.Else != nil {
+		otherwise = compileValueExpr(e.Else, strict)
+	}
+	return func(row map[string]any) (Value, error) {
+		var subject any
+		if operand != nil {
+			subject = operand.operand(row)
+		}
+		for _, b := range branches {
+			if operand == nil {
+				if !b.condition.matches(row) {
+					continue
+				}
+			} else {
+				value, err := b.value(row)
+				if err != nil || compareLiteral(subject, newLiteral(value), Eq, strict) != isTrue {
+					continue
+				}
+			}
+			return b.result(row)
+		}
+		return otherwise(row)
+	}
+}
+
+// applySign applies the sign of a UnaryExpr to a number, or to a numeric string unless strict. NULL
+// stays NULL.
+func applySign(operator ArithmeticOperator, value Value, strict bool) (Value, error) {
+	if value.Kind == NullValue {
+		return value, nil
+	}
+	number, ok := arithmeticOperand(value, strict)
+	if !ok {
+		return Value{}, fmt.Errorf("cannot apply %s to %s", operator, value)
+	}
+	if operator != Subtract {
+		return number, nil
+	}
+	if number.Kind == IntValue {
+		return NewInt(-number.Int), nil
+	}
+	return NewFloat(-number.Float), nil
+}
+
+// applyArithmetic applies an arithmetic operator to numbers and, unless strict, numeric strings, or
+// concatenates the text of two values. Integers stay integers, also when divided, and anything with
+// NULL is NULL.
+func applyArithmetic(operator ArithmeticOperator, left, right Value, strict bool) (Value, error) {
+	if left.Kind == NullValue || right.Kind == NullValue {
+		return Value{}, nil
+	}
+	if operator == Concat {
+		return NewString(left.text() + right.text()), nil
+	}
+	l, ok := arithmeticOperand(left, strict)
+	if !ok {
+		return Value{}, fmt.Errorf("cannot apply %s to %s", operator, left)
+	}
+	r, ok := arithmeticOperand(right, strict)
+	if !ok {
+		return Value{}, fmt.Errorf("cannot apply %s to %s", operator, right)
+	}
+	if (operator == Divide || operator == Modulo) && r.number() == 0 {
+		return Value{}, fmt.Errorf("division by zero")
+	}
+	if l.Kind == IntValue && r.Kind == IntValue {
+		switch operator {
+		case Add:
+			return NewInt(l.Int + r.Int), nil
+		case Subtract:
+			return NewInt(l.Int - r.Int), nil
+		case Multiply:
+			return NewInt(l.Int * r.Int), nil
+		case Divide:
+			return NewInt(l.Int / r.Int), nil
+		case Modulo:
+			return NewInt(l.Int % r.Int), nil
+		}
+	}
+	a, b := l.number(), r.number()
+	switch operator {
+	case Add:
+		return NewFloat(a + b), nil
+	case Subtract:
+		return NewFloat(a - b), nil
+	case Multiply:
+		return NewFloat(a * b), nil
+	case Divide:
+		return NewFloat(a / b), nil
+	case Modulo:
+		return NewFloat(math.Mod(a, b)), nil
+	default:
+		return Value{}, fmt.Errorf("unknown operator %s", operator)
+	}
+}
+
+// arithmeticOperand returns an operand of an arithmetic operator as a number, see toNumberValue.
+// Strict expressions only take numbers.
+func arithmeticOperand(value Value, strict bool) (Value, bool) {
+	if strict && value.Kind == StringValue {
+		return Value{}, false
+	}
+	return toNumberValue(value)
+}
+
+// toNumberValue returns a number, or a numeric string as a number
+func toNumberValue(value Value) (Value, bool) {
+	switch value.Kind {
+	case IntValue, FloatValue:
+		return value, true
+	case StringValue:
+		return parseNumber(strings.TrimSpace(value.Str))
+	default:
+		return Value{}, false
+	}
+}
+
+// fieldRefs returns the names of the fields an expression refers to, in order
+func fieldRefs(expr ValueExpr) []string {
+	switch e := expr.(type) {
+	case *FieldRef:
+		return []string{e.Name}
+	case *UnaryExpr:
+		return fieldRefs(e.Expr)
+	case *BinaryExpr:
+		return append(fieldRefs(e.Left), fieldRefs(e.Right)...)
+	case *FuncCall:
+		names := []string{}
+		for _, arg := range e.Args {
+			names = append(names, fieldRefs(arg)...)
+		}
+		return names
+	case *CastExpr:
+		return fieldRefs(e.Expr)
+	case *CaseExpr:
+		names := []string{}
+		if e.Operand != nil {
+			names = append(names, fieldRefs(e.Operand)...)
+		}
+		for _, when := range e.Whens {
+			for _, c := range conditionsOf(when.Condition) {
+				names = append(names, c.fieldRefs()...)
+			}
+			if when.Value != nil {
+				names = append(names, fieldRefs(when.Value)...)
+			}
+			names = append(names, fieldRefs(when.Result)...)
+		}
+		if e.Else != nil {
+			names = append(names, fieldRefs(e.Else)...)
+		}
+		return names
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,85 @@
+package sqlparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateValueExpr(t *testing.T) {
+	row := map[string]any{"i": 7, "f": 2.5, "s": "4", "text": "abc", "null": nil, "b": true}
+	tests := []struct {
+		sql         string
+		expected    Value
+		expectedErr string
+	}{
+		{sql: "i + 1", expected: NewInt(8)},
+		{sql: "i / 2", expected: NewInt(3)},
+		{sql: "i % 4", expected: NewInt(3)},
+		{sql: "i * f", expected: NewFloat(17.5)},
+		{sql: "f % 1", expected: NewFloat(0.5)},
+		{sql: "s * 2", expected: NewInt(8)},
+		{sql: "-i", expected: NewInt(-7)},
+		{sql: "-(f - 5)", expected: NewFloat(2.5)},
+		{sql: "+s", expected: NewInt(4)},
+		{sql: "text || i || b", expected: NewString("abc7true")},
+		{sql: "i + null", expected: Value{}},
+		{sql: "text || missing", expected: Value{}},
+		{sql: "-null", expected: Value{}},
+		{sql: "i / (s - 4)", expectedErr: "division by zero"},
+		{sql: "f % 0.0", expectedErr: "division by zero"},
+		{sql: "text + 1", expectedErr: "cannot apply + to 'abc'"},
+		{sql: "-b", expectedErr: "cannot apply - to TRUE"},
+		{sql: "CASE WHEN i > 5 THEN 'big' ELSE 'small' END", expected: NewString("big")},
+		{sql: "CASE WHEN i > 10 THEN 'big' WHEN i > 5 THEN 'medium' END", expected: NewString("medium")},
+		{sql: "CASE WHEN i > 10 THEN 'big' END", expected: Value{}},
+		{sql: "CASE WHEN null = 1 THEN 1 ELSE 2 END", expected: NewInt(2)},
+		{sql: "CASE s WHEN 3 THEN 'three' WHEN 4 THEN 'four' END", expected: NewString("four")},
+		{sql: "CASE null WHEN null THEN 1 ELSE 0 END", expected: NewInt(0)},
+		{sql: "CASE WHEN i / 0 > 1 THEN 1 ELSE 0 END", expected: NewInt(0)},
+		{sql: "CASE WHEN i > 5 THEN i / 0 END", expectedErr: "division by zero"},
+		{sql: "CAST(s AS INT) + 1", expected: NewInt(5)},
+		{sql: "f::INT", expected: NewInt(2)},
+		{sql: "-i::TEXT || text", expected: NewString("-7abc")},
+		{sql: "i::TEXT || text", expected: NewString("7abc")},
+		{sql: "(i / 2)::FLOAT", expected: NewFloat(3)},
+		{sql: "null::INT", expected: Value{}},
+		{sql: "CAST(text AS INT)", expectedErr: "cannot cast 'abc' to INT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			q, err := Parse("UPDATE t SET x = " + tt.sql + " WHERE y = 1")
+			require.NoError(t, err)
+			value, err := evaluateValueExpr(q.Assignments[0].Value, row)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, value)
+		})
+	}
+}
+
+func TestValueExprString(t *testing.T) {
+	a, b, c := &FieldRef{Name: "a"}, &FieldRef{Name: "b"}, &FieldRef{Name: "c d", Quoted: true}
+	tests := []struct {
+		expr     ValueExpr
+		expected string
+	}{
+		{expr: &BinaryExpr{Operator: Subtract, Left: &BinaryExpr{Operator: Subtract, Left: a, Right: b}, Right: c}, expected: `a - b - "c d"`},
+		{expr: &BinaryExpr{Operator: Subtract, Left: a, Right: &BinaryExpr{Operator: Subtract, Left: b, Right: c}}, expected: `a - (b - "c d")`},
+		{expr: &BinaryExpr{Operator: Multiply, Left: &BinaryExpr{Operator: Add, Left: a, Right: b}, Right: c}, expected: `(a + b) * "c d"`},
+		{expr: &BinaryExpr{Operator: Add, Left: a, Right: &BinaryExpr{Operator: Modulo, Left: b, Right: c}}, expected: `a + b % "c d"`},
+		{expr: &BinaryExpr{Operator: Concat, Left: a, Right: &BinaryExpr{Operator: Add, Left: b, Right: c}}, expected: `a || b + "c d"`},
+		{expr: &UnaryExpr{Operator: Subtract, Expr: &Literal{Value: NewInt(-1)}}, expected: "-(-1)"},
+		{expr: &UnaryExpr{Operator: Subtract, Expr: &BinaryExpr{Operator: Multiply, Left: a, Right: b}}, expected: "-(a * b)"},
+		{expr: &FuncCall{Name: "now"}, expected: "now()"},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, tt.expr.String())
+		q, err := Parse("UPDATE t SET x = " + tt.expected + " WHERE y = 1")
+		require.NoError(t, err, tt.expected)
+		require.Equal(t, tt.expr, q.Assignments[0].Value, tt.expected)
+	}
+}
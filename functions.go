@@ -0,0 +1,225 @@
+package sqlparser
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Function is the implementation of a scalar function. It is called with a number of arguments within
+// its registered arity, and returns an error for arguments it can't handle.
+type Function func(args []Value) (Value, error)
+
+// Variadic is the arity of functions taking any number of arguments, and the maximum arity of functions
+// taking any number of arguments from a minimum
+const Variadic = -1
+
+type registeredFunction struct {
+	minArity int
+	maxArity int // Variadic for no maximum
+	impl     Function
+}
+
+var (
+	functionsMu sync.RWMutex
+	// functions are the scalar functions by upper case name
+	functions = map[string]registeredFunction{}
+)
+
+func init() {
+	builtins := []struct {
+		name     string
+		minArity int
+		maxArity int
+		impl     Function
+	}{
+		{"LOWER", 1, 1, textFunction(strings.ToLower)},
+		{"UPPER", 1, 1, textFunction(strings.ToUpper)},
+		{"LENGTH", 1, 1, lengthFunction},
+		{"SUBSTR", 2, 3, substrFunction},
+		{"ABS", 1, 1, absFunction},
+		{"ROUND", 1, 2, roundFunction},
+		{"COALESCE", 1, Variadic, coalesceFunction},
+		{"NOW", 0, 0, nowFunction},
+	}
+	for _, builtin := range builtins {
+		if err := RegisterVariadicFunction(builtin.name, builtin.minArity, builtin.maxArity, builtin.impl); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterFunction makes a scalar function callable by name, case-insensitively, in the expressions of
+// all queries. arity is the number of arguments the function takes, or Variadic. Registering a name
+// again replaces the function, including the built-in LOWER, UPPER, LENGTH, SUBSTR, ABS, ROUND, COALESCE
+// and NOW. The names of aggregate functions and reserved words such as CASE and CAST can't be registered.
+func RegisterFunction(name string, arity int, impl Function) error {
+	if arity == Variadic {
+		return RegisterVariadicFunction(name, 0, Variadic, impl)
+	}
+	return RegisterVariadicFunction(name, arity, arity, impl)
+}
+
+// RegisterVariadicFunction registers a scalar function like RegisterFunction, taking from minArity to
+// maxArity arguments, or any number of arguments from minArity when maxArity is Variadic
+func RegisterVariadicFunction(name string, minArity, maxArity int, impl Function) error {
+	if !isIdentifier(name) || strings.Contains(name, ".") || strings.EqualFold(name, "CAST") {
+		return fmt.Errorf("invalid function name %q", name)
+	}
+	if aggregateFunction(name) != UnknownAggregate {
+		return fmt.Errorf("%s is an aggregate function", strings.ToUpper(name))
+	}
+	if minArity < 0 {
+		return fmt.Errorf("invalid arity %d for function %s", minArity, strings.ToUpper(name))
+	}
+	if maxArity != Variadic && maxArity < minArity {
+		return fmt.Errorf("invalid arity %d to %d for function %s", minArity, maxArity, strings.ToUpper(name))
+	}
+	if impl == nil {
+		return fmt.Errorf("function %s has no implementation", strings.ToUpper(name))
+	}
+	functionsMu.Lock()
+	defer functionsMu.Unlock()
+	functions[strings.ToUpper(name)] = registeredFunction{minArity: minArity, maxArity: maxArity, impl: impl}
+	return nil
+}
+
+// lookupFunction returns the registered function of a name
+func lookupFunction(name string) (registeredFunction, bool) {
+	functionsMu.RLock()
+	defer functionsMu.RUnlock()
+	function, ok := functions[strings.ToUpper(name)]
+	return function, ok
+}
+
+// checkArity returns an error when a function is called with the wrong number of arguments
+func (f registeredFunction) checkArity(name string, count int) error {
+	switch {
+	case count >= f.minArity && (f.maxArity == Variadic || count <= f.maxArity):
+		return nil
+	case f.maxArity == Variadic:
+		return fmt.Errorf("%s takes at least %d argument(s), got %d", strings.ToUpper(name), f.minArity, count)
+	case f.minArity == f.maxArity:
+		return fmt.Errorf("%s takes %d argument(s), got %d", strings.ToUpper(name), f.minArity, count)
+	case f.minArity+1 == f.maxArity:
+		return fmt.Errorf("%s takes %d or %d arguments, got %d", strings.ToUpper(name), f.minArity, f.maxArity, count)
+	default:
+		return fmt.Errorf("%s takes %d to %d arguments, got %d", strings.ToUpper(name), f.minArity, f.maxArity, count)
+	}
+}
+
+// textFunction returns a function transforming the text of its argument. NULL stays NULL.
+func textFunction(transform func(string) string) Function {
+	return func(args []Value) (Value, error) {
+		if args[0].Kind == NullValue {
+			return Value{}, nil
+		}
+		return NewString(transform(args[0].text())), nil
+	}
+}
+
+// lengthFunction returns the number of characters of the text of its argument
+func lengthFunction(args []Value) (Value, error) {
+	if args[0].Kind == NullValue {
+		return Value{}, nil
+	}
+	return NewInt(int64(utf8.RuneCountInString(args[0].text()))), nil
+}
+
+// substrFunction returns the characters of a text from a 1-based start, which counts from the end when
+// negative, up to an optional length
+func substrFunction(args []Value) (Value, error) {
+	for _, arg := range args {
+		if arg.Kind == NullValue {
+			return Value{}, nil
+		}
+	}
+	runes := []rune(args[0].text())
+	start, ok := toNumberValue(args[1])
+	if !ok || start.Kind != IntValue {
+		return Value{}, fmt.Errorf("SUBSTR start must be an integer, got %s", args[1])
+	}
+	from := int(start.Int) - 1
+	if start.Int < 0 {
+		from = len(runes) + int(start.Int)
+	}
+	to := len(runes)
+	if len(args) == 3 {
+		length, ok := toNumberValue(args[2])
+		if !ok || length.Kind != IntValue || length.Int < 0 {
+			return Value{}, fmt.Errorf("SUBSTR length must be a non-negative integer, got %s", args[2])
+		}
+		if int64(from)+length.Int < int64(to) {
+			to = from + int(length.Int)
+		}
+	}
+	if from < 0 {
+		from = 0
+	}
+	if from >= to {
+		return NewString(""), nil
+	}
+	return NewString(string(runes[from:to])), nil
+}
+
+// absFunction returns the absolute value of a number
+func absFunction(args []Value) (Value, error) {
+	if args[0].Kind == NullValue {
+		return Value{}, nil
+	}
+	number, ok := toNumberValue(args[0])
+	if !ok {
+		return Value{}, fmt.Errorf("ABS expects a number, got %s", args[0])
+	}
+	if number.Kind == IntValue {
+		if number.Int < 0 {
+			return NewInt(-number.Int), nil
+		}
+		return number, nil
+	}
+	return NewFloat(math.Abs(number.Float)), nil
+}
+
+// roundFunction rounds a number half away from zero to an optional number of decimal places. Integers
+// are left as they are.
+func roundFunction(args []Value) (Value, error) {
+	for _, arg := range args {
+		if arg.Kind == NullValue {
+			return Value{}, nil
+		}
+	}
+	number, ok := toNumberValue(args[0])
+	if !ok {
+		return Value{}, fmt.Errorf("ROUND expects a number, got %s", args[0])
+	}
+	places := NewInt(0)
+	if len(args) == 2 {
+		places, ok = toNumberValue(args[1])
+		if !ok || places.Kind != IntValue {
+			return Value{}, fmt.Errorf("ROUND decimal places must be an integer, got %s", args[1])
+		}
+	}
+	if number.Kind == IntValue {
+		return number, nil
+	}
+	scale := math.Pow(10, float64(places.Int))
+	return NewFloat(math.Round(number.Float*scale) / scale), nil
+}
+
+// coalesceFunction returns its first argument that is not NULL
+func coalesceFunction(args []Value) (Value, error) {
+	for _, arg := range args {
+		if arg.Kind != NullValue {
+			return arg, nil
+		}
+	}
+	return Value{}, nil
+}
+
+// nowFunction returns the current time in UTC, formatted as RFC 3339
+func nowFunction([]Value) (Value, error) {
+	return NewString(time.Now().UTC().Format(time.RFC3339)), nil
+}
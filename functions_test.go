@@ -0,0 +1,153 @@
+package sqlparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltinFunctions(t *testing.T) {
+	row := map[string]any{"name": "Née", "temp": "-21.56", "count": 3, "null": nil}
+	tests := []struct {
+		sql         string
+		expected    Value
+		expectedErr string
+	}{
+		{sql: "LOWER(name)", expected: NewString("née")},
+		{sql: "upper(name)", expected: NewString("NÉE")},
+		{sql: "LOWER(count)", expected: NewString("3")},
+		{sql: "LENGTH(name)", expected: NewInt(3)},
+		{sql: "LENGTH(temp)", expected: NewInt(6)},
+		{sql: "SUBSTR('hello', 2)", expected: NewString("ello")},
+		{sql: "SUBSTR('hello', 2, 3)", expected: NewString("ell")},
+		{sql: "SUBSTR('hello', -3)", expected: NewString("llo")},
+		{sql: "SUBSTR('hello', 0, 2)", expected: NewString("h")},
+		{sql: "SUBSTR('hello', 9)", expected: NewString("")},
+		{sql: "SUBSTR(name, 2, count)", expected: NewString("ée")},
+		{sql: "ABS(temp)", expected: NewFloat(21.56)},
+		{sql: "ABS(-count)", expected: NewInt(3)},
+		{sql: "ROUND(temp)", expected: NewFloat(-22)},
+		{sql: "ROUND(temp, 1)", expected: NewFloat(-21.6)},
+		{sql: "ROUND(2.5)", expected: NewFloat(3)},
+		{sql: "ROUND(count, 2)", expected: NewInt(3)},
+		{sql: "COALESCE(null, missing, count + 1, 'x')", expected: NewInt(4)},
+		{sql: "COALESCE(null)", expected: Value{}},
+		{sql: "LOWER(null)", expected: Value{}},
+		{sql: "SUBSTR(null, 1)", expected: Value{}},
+		{sql: "ROUND(ABS(temp) * 2, 1) || '!'", expected: NewString("43.1!")},
+		{sql: "SUBSTR('hello', 1.5)", expectedErr: "SUBSTR start must be an integer, got 1.5"},
+		{sql: "ABS(name)", expectedErr: "ABS expects a number, got 'Née'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			q, err := Parse("UPDATE t SET x = " + tt.sql + " WHERE y = 1")
+			require.NoError(t, err)
+			value, err := evaluateValueExpr(q.Assignments[0].Value, row)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, value)
+		})
+	}
+}
+
+func TestFunctionArity(t *testing.T) {
+	require.NoError(t, RegisterVariadicFunction("join_words", 2, 4, func(args []Value) (Value, error) {
+		return NewString(joinValues(args)), nil
+	}))
+	t.Cleanup(func() {
+		functionsMu.Lock()
+		delete(functions, "JOIN_WORDS")
+		functionsMu.Unlock()
+	})
+
+	tests := []struct {
+		sql         string
+		expectedErr string
+	}{
+		{sql: "SUBSTR('hello')", expectedErr: "at WHERE: SUBSTR takes 2 or 3 arguments, got 1"},
+		{sql: "ROUND(1.5, 1, 2)", expectedErr: "at WHERE: ROUND takes 1 or 2 arguments, got 3"},
+		{sql: "COALESCE()", expectedErr: "at WHERE: COALESCE takes at least 1 argument(s), got 0"},
+		{sql: "NOW(1)", expectedErr: "at WHERE: NOW takes 0 argument(s), got 1"},
+		{sql: "JOIN_WORDS(a)", expectedErr: "at WHERE: JOIN_WORDS takes 2 to 4 arguments, got 1"},
+		{sql: "JOIN_WORDS(a, 'b', 'c', 'd', 'e')", expectedErr: "at WHERE: JOIN_WORDS takes 2 to 4 arguments, got 5"},
+		{sql: "JOIN_WORDS(a, 'b', 'c', 'd')"},
+		{sql: "COALESCE(a, 'b', 'c', 'd', 'e')"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			_, err := Parse("SELECT a FROM t WHERE " + tt.sql + " = 'x'")
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+
+	rows, err := FilterProjected("SELECT JOIN_WORDS(a, 'b', 'c') AS w FROM t", map[string]map[string]any{"1": {"a": "x"}})
+	require.NoError(t, err)
+	require.Equal(t, []map[string]any{{"w": "'x', 'b', 'c'"}}, rows)
+
+	require.EqualError(t, RegisterVariadicFunction("f", 2, 1, crc16), "invalid arity 2 to 1 for function F")
+	require.EqualError(t, RegisterVariadicFunction("f", -1, Variadic, crc16), "invalid arity -1 for function F")
+}
+
+// crc16 is the CRC-16/MODBUS checksum of a text
+func crc16(args []Value) (Value, error) {
+	crc := uint16(0xFFFF)
+	for _, b := range []byte(args[0].text()) {
+		crc ^= uint16(b)
+		for i := 0; i < 8; i++ {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0xA001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return NewInt(int64(crc)), nil
+}
+
+func TestRegisterFunction(t *testing.T) {
+	require.NoError(t, RegisterFunction("crc16", 1, crc16))
+	t.Cleanup(func() {
+		functionsMu.Lock()
+		delete(functions, "CRC16")
+		functionsMu.Unlock()
+	})
+
+	data := map[string]map[string]any{
+		"1": {"payload": "123456789"},
+		"2": {"payload": "12345678"},
+	}
+	rows, err := FilterRecursive("SELECT * FROM t WHERE CRC16(payload) = 19255", data)
+	require.NoError(t, err)
+	require.Equal(t, map[string]map[string]any{"1": data["1"]}, rows)
+
+	projected, err := FilterProjected("SELECT CRC16(payload) AS crc FROM t ORDER BY crc", data)
+	require.NoError(t, err)
+	require.Equal(t, []map[string]any{{"crc": int64(14301)}, {"crc": int64(19255)}}, projected)
+
+	_, err = Parse("SELECT * FROM t WHERE CRC16(a, b) = 1")
+	require.EqualError(t, err, "at WHERE: CRC16 takes 1 argument(s), got 2")
+
+	// Registering a name again replaces the function, but not in compiled queries
+	program, err := Compile("SELECT * FROM t WHERE CRC16(payload) = 19255")
+	require.NoError(t, err)
+	require.NoError(t, RegisterFunction("CRC16", 1, func(args []Value) (Value, error) {
+		return NewString("xx"), nil
+	}))
+	require.Equal(t, map[string]map[string]any{"1": data["1"]}, program.Filter(data))
+	rows, err = FilterRecursive("SELECT * FROM t WHERE crc16(payload) = 'xx'", data)
+	require.NoError(t, err)
+	require.Equal(t, data, rows)
+
+	require.EqualError(t, RegisterFunction("Count", 1, crc16), "COUNT is an aggregate function")
+	require.EqualError(t, RegisterFunction("my func", 1, crc16), `invalid function name "my func"`)
+	require.EqualError(t, RegisterFunction("a.b", 1, crc16), `invalid function name "a.b"`)
+	require.EqualError(t, RegisterFunction("f", -2, crc16), "invalid arity -2 for function F")
+	require.EqualError(t, RegisterFunction("f", 1, nil), "function F has no implementation")
+}
@@ -0,0 +1,298 @@
+package sqlparser
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TokenKind is the kind of a lexical token
+type TokenKind int
+
+const (
+	// UnknownToken is a character that doesn't start any token, or an unterminated string or comment
+	UnknownToken TokenKind = iota
+	// KeywordToken is a keyword such as SELECT or NOT LIKE
+	KeywordToken
+	// IdentifierToken is a name such as a field, table or function name, possibly quoted
+	IdentifierToken
+	// StringToken is a single quoted string
+	StringToken
+	// NumberToken is an unquoted number such as 10, -2 or 1.5e3
+	NumberToken
+	// OperatorToken is an operator such as >= or *
+	OperatorToken
+	// PunctuationToken is a parenthesis, comma or semicolon
+	PunctuationToken
+)
+
+// TokenKindString is a string slice with the names of all token kinds in order
+var TokenKindString = []string{
+	"UnknownToken",
+	"KeywordToken",
+	"IdentifierToken",
+	"StringToken",
+	"NumberToken",
+	"OperatorToken",
+	"PunctuationToken",
+}
+
+func (k TokenKind) String() string {
+	if k < 0 || int(k) >= len(TokenKindString) {
+		return TokenKindString[UnknownToken]
+	}
+	return TokenKindString[k]
+}
+
+// Token is a lexical token of a query
+type Token struct {
+	Kind TokenKind
+	// Text is the token as written in the query
+	Text string
+	// Value is the token as the parser reads it: keywords are upper case, strings and quoted identifiers
+	// are unquoted
+	Value string
+	// Quoted is set for identifiers written in double quotes or backticks
+	Quoted bool
+	// Offset is the byte offset of the token in the query
+	Offset int
+	// Line and Column are 1-based, Column counts characters and not bytes
+	Line   int
+	Column int
+}
+
+// keywords are the words the parser gives a meaning to. They are matched case-insensitively.
+var keywords = map[string]bool{
+	"SELECT": true, "INSERT": true, "INTO": true, "VALUES": true, "UPDATE": true, "SET": true,
+	"DELETE": true, "FROM": true, "CREATE": true, "TABLE": true, "WHERE": true, "AS": true,
+	"AND": true, "OR": true, "NOT": true, "LIKE": true, "IN": true, "GROUP": true, "BY": true,
+	"HAVING": true, "ORDER": true, "ASC": true, "DESC": true, "LIMIT": true, "OFFSET": true,
+	"TRUE": true, "FALSE": true, "NULL": true, "IS": true,
+	"BETWEEN": true, "PRIMARY": true, "FOREIGN": true, "KEY": true, "UNIQUE": true, "CHECK": true,
+	"DEFAULT": true, "REFERENCES": true, "CONSTRAINT": true, "IF": true, "EXISTS": true, "DROP": true,
+	"ALTER": true, "TRUNCATE": true, "ADD": true, "COLUMN": true, "RENAME": true, "TO": true, "TYPE": true,
+	"CASE": true, "WHEN": true, "THEN": true, "ELSE": true, "END": true, "CAST": true,
+}
+
+// multiWordKeywords maps the first word of keywords made of two words to the possible second words
+var multiWordKeywords = map[string][]string{
+	"INSERT":  {"INTO"},
+	"DELETE":  {"FROM"},
+	"CREATE":  {"TABLE"},
+	"DROP":    {"TABLE"},
+	"ALTER":   {"TABLE"},
+	"PRIMARY": {"KEY"},
+	"FOREIGN": {"KEY"},
+	"NOT":     {"LIKE", "IN", "BETWEEN"},
+}
+
+// operators are the operator tokens, longest first
+var operators = []string{"::", ">=", "<=", "!=", "||", "=", ">", "<", "*", "+", "-", "/", "%"}
+
+// Tokenize splits a query into tokens. Whitespace, "--" line comments and "/* */" block comments
+// separate tokens and are left out. Tokenize never fails: text that can't be tokenized is returned as
+// UnknownToken tokens for the parser to report.
+func Tokenize(sql string) []Token {
+	l := lexer{sql: sql, line: 1, column: 1}
+	tokens := []Token{}
+	for {
+		l.skip()
+		if l.i >= len(l.sql) {
+			return tokens
+		}
+		tokens = append(tokens, l.next())
+		l.afterOperand = endsOperand(tokens[len(tokens)-1])
+	}
+}
+
+type lexer struct {
+	sql    string
+	i      int
+	line   int
+	column int
+	// afterOperand is set when the previous token ends an operand, so that a following minus sign
+	// is not the sign of a number
+	afterOperand bool
+}
+
+// endsOperand reports whether a token can be the last token of an operand
+func endsOperand(token Token) bool {
+	switch token.Kind {
+	case IdentifierToken, StringToken, NumberToken:
+		return true
+	case KeywordToken:
+		return token.Value == "TRUE" || token.Value == "FALSE" || token.Value == "NULL" || token.Value == "END"
+	default:
+		return token.Value == ")"
+	}
+}
+
+// advance moves past n bytes, keeping track of the line and column
+func (l *lexer) advance(n int) {
+	for _, r := range l.sql[l.i : l.i+n] {
+		if r == '\n' {
+			l.line++
+			l.column = 1
+		} else {
+			l.column++
+		}
+	}
+	l.i += n
+}
+
+// skip moves past whitespace and comments
+func (l *lexer) skip() {
+	for l.i < len(l.sql) {
+		rest := l.sql[l.i:]
+		r, size := utf8.DecodeRuneInString(rest)
+		switch {
+		case unicode.IsSpace(r):
+			l.advance(size)
+		case strings.HasPrefix(rest, "--"):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			l.advance(end)
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				return // unterminated, returned as an unknown token
+			}
+			l.advance(end + 4)
+		default:
+			return
+		}
+	}
+}
+
+// next scans the token starting at the current position, which is not whitespace
+func (l *lexer) next() Token {
+	start, line, column := l.i, l.line, l.column
+	kind, value, n := l.scan(l.sql[l.i:])
+	l.advance(n)
+	if kind == KeywordToken {
+		kind, value = l.multiWordKeyword(value)
+	}
+	text := l.sql[start:l.i]
+	quoted := kind == IdentifierToken && (text[0] == '"' || text[0] == '`')
+	return Token{Kind: kind, Text: text, Value: value, Quoted: quoted, Offset: start, Line: line, Column: column}
+}
+
+// scan returns the kind, value and length of the token at the start of s
+func (l *lexer) scan(s string) (TokenKind, string, int) {
+	r, size := utf8.DecodeRuneInString(s)
+	switch {
+	case r == '\'':
+		for i := 1; i < len(s); i++ {
+			if s[i] == '\'' && s[i-1] != '\\' {
+				return StringToken, s[1:i], i + 1
+			}
+		}
+		return UnknownToken, s, len(s)
+	case r == '"' || r == '`':
+		// A quote is written twice inside a quoted identifier
+		quote := s[:1]
+		for i := 1; i < len(s); i++ {
+			if s[i] != quote[0] {
+				continue
+			}
+			if i+1 < len(s) && s[i+1] == quote[0] {
+				i++
+				continue
+			}
+			return IdentifierToken, strings.ReplaceAll(s[1:i], quote+quote, quote), i + 1
+		}
+		return UnknownToken, s, len(s)
+	case strings.HasPrefix(s, "/*"):
+		return UnknownToken, s, len(s)
+	case isDigit(r) || r == '.' && len(s) > 1 && isDigit(rune(s[1])):
+		n := scanNumber(s)
+		return NumberToken, s[:n], n
+	case r == '-' && !l.afterOperand && len(s) > 1 && (isDigit(rune(s[1])) || s[1] == '.' && len(s) > 2 && isDigit(rune(s[2]))):
+		n := 1 + scanNumber(s[1:])
+		return NumberToken, s[:n], n
+	case isWordStart(r):
+		n := size
+		for n < len(s) {
+			r, size := utf8.DecodeRuneInString(s[n:])
+			if !isWordPart(r) {
+				break
+			}
+			n += size
+		}
+		if word := strings.ToUpper(s[:n]); keywords[word] {
+			return KeywordToken, word, n
+		}
+		return IdentifierToken, s[:n], n
+	case r == '(' || r == ')' || r == ',' || r == ';':
+		return PunctuationToken, s[:1], 1
+	}
+	for _, operator := range operators {
+		if strings.HasPrefix(s, operator) {
+			return OperatorToken, operator, len(operator)
+		}
+	}
+	return UnknownToken, s[:size], size
+}
+
+// multiWordKeyword extends the keyword just scanned with the following word when they form a keyword
+// of two words. Any whitespace and comments may separate the words.
+func (l *lexer) multiWordKeyword(first string) (TokenKind, string) {
+	seconds := multiWordKeywords[first]
+	if len(seconds) == 0 {
+		return KeywordToken, first
+	}
+	saved := *l
+	l.skip()
+	rest := l.sql[l.i:]
+	for _, second := range seconds {
+		if len(rest) < len(second) || !strings.EqualFold(rest[:len(second)], second) {
+			continue
+		}
+		if r, _ := utf8.DecodeRuneInString(rest[len(second):]); isWordPart(r) {
+			continue
+		}
+		l.advance(len(second))
+		return KeywordToken, first + " " + second
+	}
+	*l = saved
+	return KeywordToken, first
+}
+
+// scanNumber returns the length of the number at the start of s
+func scanNumber(s string) int {
+	n := 0
+	for n < len(s) && isDigit(rune(s[n])) {
+		n++
+	}
+	if n < len(s) && s[n] == '.' {
+		n++
+		for n < len(s) && isDigit(rune(s[n])) {
+			n++
+		}
+	}
+	if n < len(s) && (s[n] == 'e' || s[n] == 'E') {
+		m := n + 1
+		if m < len(s) && (s[m] == '+' || s[m] == '-') {
+			m++
+		}
+		if m < len(s) && isDigit(rune(s[m])) {
+			for n = m; n < len(s) && isDigit(rune(s[n])); n++ {
+			}
+		}
+	}
+	return n
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func isWordStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isWordPart(r rune) bool {
+	return r == '_' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
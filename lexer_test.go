@@ -0,0 +1,117 @@
+package sqlparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		expected []Token
+	}{
+		{
+			name: "token kinds",
+			sql:  "select a.b, COUNT(*) from 'it''s' WHERE c >= 1.5e3;",
+			expected: []Token{
+				{Kind: KeywordToken, Text: "select", Value: "SELECT", Offset: 0, Line: 1, Column: 1},
+				{Kind: IdentifierToken, Text: "a.b", Value: "a.b", Offset: 7, Line: 1, Column: 8},
+				{Kind: PunctuationToken, Text: ",", Value: ",", Offset: 10, Line: 1, Column: 11},
+				{Kind: IdentifierToken, Text: "COUNT", Value: "COUNT", Offset: 12, Line: 1, Column: 13},
+				{Kind: PunctuationToken, Text: "(", Value: "(", Offset: 17, Line: 1, Column: 18},
+				{Kind: OperatorToken, Text: "*", Value: "*", Offset: 18, Line: 1, Column: 19},
+				{Kind: PunctuationToken, Text: ")", Value: ")", Offset: 19, Line: 1, Column: 20},
+				{Kind: KeywordToken, Text: "from", Value: "FROM", Offset: 21, Line: 1, Column: 22},
+				{Kind: StringToken, Text: "'it'", Value: "it", Offset: 26, Line: 1, Column: 27},
+				{Kind: StringToken, Text: "'s'", Value: "s", Offset: 30, Line: 1, Column: 31},
+				{Kind: KeywordToken, Text: "WHERE", Value: "WHERE", Offset: 34, Line: 1, Column: 35},
+				{Kind: IdentifierToken, Text: "c", Value: "c", Offset: 40, Line: 1, Column: 41},
+				{Kind: OperatorToken, Text: ">=", Value: ">=", Offset: 42, Line: 1, Column: 43},
+				{Kind: NumberToken, Text: "1.5e3", Value: "1.5e3", Offset: 45, Line: 1, Column: 46},
+				{Kind: PunctuationToken, Text: ";", Value: ";", Offset: 50, Line: 1, Column: 51},
+			},
+		},
+		{
+			name: "whitespace and comments",
+			sql:  "-- leading\n\tNOT LIKE\u00a0/* block\ncomment */ température\u2003x --",
+			expected: []Token{
+				{Kind: KeywordToken, Text: "NOT LIKE", Value: "NOT LIKE", Offset: 12, Line: 2, Column: 2},
+				{Kind: IdentifierToken, Text: "température", Value: "température", Offset: 42, Line: 3, Column: 12},
+				{Kind: IdentifierToken, Text: "x", Value: "x", Offset: 57, Line: 3, Column: 24},
+			},
+		},
+		{
+			name: "keywords on word boundaries",
+			sql:  "NOT /* x */\n in NOT INDEX asset",
+			expected: []Token{
+				{Kind: KeywordToken, Text: "NOT /* x */\n in", Value: "NOT IN", Offset: 0, Line: 1, Column: 1},
+				{Kind: KeywordToken, Text: "NOT", Value: "NOT", Offset: 16, Line: 2, Column: 5},
+				{Kind: IdentifierToken, Text: "INDEX", Value: "INDEX", Offset: 20, Line: 2, Column: 9},
+				{Kind: IdentifierToken, Text: "asset", Value: "asset", Offset: 26, Line: 2, Column: 15},
+			},
+		},
+		{
+			name: "quoted identifiers",
+			sql:  "\"Device \"\"ID\"\"\" `a``b` \"",
+			expected: []Token{
+				{Kind: IdentifierToken, Text: "\"Device \"\"ID\"\"\"", Value: "Device \"ID\"", Quoted: true, Offset: 0, Line: 1, Column: 1},
+				{Kind: IdentifierToken, Text: "`a``b`", Value: "a`b", Quoted: true, Offset: 16, Line: 1, Column: 17},
+				{Kind: UnknownToken, Text: "\"", Value: "\"", Offset: 23, Line: 1, Column: 24},
+			},
+		},
+		{
+			name: "unknown characters and unterminated strings",
+			sql:  "a ~ 'b",
+			expected: []Token{
+				{Kind: IdentifierToken, Text: "a", Value: "a", Offset: 0, Line: 1, Column: 1},
+				{Kind: UnknownToken, Text: "~", Value: "~", Offset: 2, Line: 1, Column: 3},
+				{Kind: UnknownToken, Text: "'b", Value: "'b", Offset: 4, Line: 1, Column: 5},
+			},
+		},
+		{
+			name: "arithmetic operators and signs",
+			sql:  "a-1 || -b%-2/+3",
+			expected: []Token{
+				{Kind: IdentifierToken, Text: "a", Value: "a", Offset: 0, Line: 1, Column: 1},
+				{Kind: OperatorToken, Text: "-", Value: "-", Offset: 1, Line: 1, Column: 2},
+				{Kind: NumberToken, Text: "1", Value: "1", Offset: 2, Line: 1, Column: 3},
+				{Kind: OperatorToken, Text: "||", Value: "||", Offset: 4, Line: 1, Column: 5},
+				{Kind: OperatorToken, Text: "-", Value: "-", Offset: 7, Line: 1, Column: 8},
+				{Kind: IdentifierToken, Text: "b", Value: "b", Offset: 8, Line: 1, Column: 9},
+				{Kind: OperatorToken, Text: "%", Value: "%", Offset: 9, Line: 1, Column: 10},
+				{Kind: NumberToken, Text: "-2", Value: "-2", Offset: 10, Line: 1, Column: 11},
+				{Kind: OperatorToken, Text: "/", Value: "/", Offset: 12, Line: 1, Column: 13},
+				{Kind: OperatorToken, Text: "+", Value: "+", Offset: 13, Line: 1, Column: 14},
+				{Kind: NumberToken, Text: "3", Value: "3", Offset: 14, Line: 1, Column: 15},
+			},
+		},
+		{
+			name: "type conversions",
+			sql:  "CAST(a AS int)-1::TEXT",
+			expected: []Token{
+				{Kind: KeywordToken, Text: "CAST", Value: "CAST", Offset: 0, Line: 1, Column: 1},
+				{Kind: PunctuationToken, Text: "(", Value: "(", Offset: 4, Line: 1, Column: 5},
+				{Kind: IdentifierToken, Text: "a", Value: "a", Offset: 5, Line: 1, Column: 6},
+				{Kind: KeywordToken, Text: "AS", Value: "AS", Offset: 7, Line: 1, Column: 8},
+				{Kind: IdentifierToken, Text: "int", Value: "int", Offset: 10, Line: 1, Column: 11},
+				{Kind: PunctuationToken, Text: ")", Value: ")", Offset: 13, Line: 1, Column: 14},
+				{Kind: OperatorToken, Text: "-", Value: "-", Offset: 14, Line: 1, Column: 15},
+				{Kind: NumberToken, Text: "1", Value: "1", Offset: 15, Line: 1, Column: 16},
+				{Kind: OperatorToken, Text: "::", Value: "::", Offset: 16, Line: 1, Column: 17},
+				{Kind: IdentifierToken, Text: "TEXT", Value: "TEXT", Offset: 18, Line: 1, Column: 19},
+			},
+		},
+		{
+			name:     "only comments",
+			sql:      " /* a */ -- b",
+			expected: []Token{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, Tokenize(tt.sql))
+		})
+	}
+}
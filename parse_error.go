@@ -0,0 +1,119 @@
+package sqlparser
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseError is the error returned when a query can't be parsed. Its position refers to the SQL string
+// given to Parse.
+type ParseError struct {
+	SQL     string
+	Message string
+	// Offset is the byte offset of the error in SQL
+	Offset int
+	// Line and Column are 1-based, Column counts characters and not bytes
+	Line   int
+	Column int
+	// Token is the text found at Offset, empty at the end of the query
+	Token string
+	// Expected lists what would have been valid at Offset: keywords and punctuation as written in SQL,
+	// and descriptions such as "field" or "quoted value" for everything else
+	Expected []string
+}
+
+// Error returns the error message, without position.
+func (e *ParseError) Error() string {
+	return e.Message
+}
+
+// Format implements fmt.Formatter. The %+v verb renders the Diagram of the error, other verbs render the
+// message only.
+func (e *ParseError) Format(f fmt.State, verb rune) {
+	if verb != 'v' || !f.Flag('+') {
+		fmt.Fprintf(f, formatString(f, verb), e.Message)
+		return
+	}
+	fmt.Fprint(f, e.Diagram())
+}
+
+// formatString returns the directive that f was formatted with, such as %-10s
+func formatString(f fmt.State, verb rune) string {
+	var sb strings.Builder
+	sb.WriteByte('%')
+	for _, flag := range "+-# 0" {
+		if f.Flag(int(flag)) {
+			sb.WriteRune(flag)
+		}
+	}
+	if width, ok := f.Width(); ok {
+		sb.WriteString(strconv.Itoa(width))
+	}
+	if precision, ok := f.Precision(); ok {
+		sb.WriteByte('.')
+		sb.WriteString(strconv.Itoa(precision))
+	}
+	sb.WriteRune(verb)
+	return sb.String()
+}
+
+// Diagram renders the line of the query containing the error, a caret under the offending position and
+// the error message followed by the expected tokens. Tabs before the position are kept in the padding
+// of the caret, so that it lines up with the query however tabs are displayed.
+//
+// Diagram is the helper rendering the caret diagram on demand. It isn't named Format because Format
+// implements fmt.Formatter, so that the diagram is also rendered by %+v.
+func (e *ParseError) Diagram() string {
+	start := strings.LastIndexByte(e.SQL[:e.Offset], '\n') + 1
+	end := strings.IndexByte(e.SQL[e.Offset:], '\n')
+	if end < 0 {
+		end = len(e.SQL)
+	} else {
+		end += e.Offset
+	}
+	var sb strings.Builder
+	sb.WriteString(e.SQL[start:end])
+	sb.WriteString("\n")
+	for _, r := range e.SQL[start:e.Offset] {
+		if r == '\t' {
+			sb.WriteRune('\t')
+		} else {
+			sb.WriteRune(' ')
+		}
+	}
+	sb.WriteString("^\n")
+	sb.WriteString(e.Message)
+	if len(e.Expected) > 0 {
+		expected := make([]string, len(e.Expected))
+		for i, token := range e.Expected {
+			expected[i] = strconv.Quote(token)
+		}
+		sb.WriteString(" (expected " + strings.Join(expected, " or ") + ")")
+	}
+	return sb.String()
+}
+
+// newParseError positions err at offset in sql, keeping the expected tokens of a wrapped ParseError
+func newParseError(sql string, offset int, token string, err error) *ParseError {
+	e := &ParseError{SQL: sql, Message: err.Error(), Offset: offset, Line: 1, Column: 1, Token: token}
+	var inner *ParseError
+	if errors.As(err, &inner) {
+		e.Expected = inner.Expected
+	}
+	for _, r := range sql[:offset] {
+		if r == '\n' {
+			e.Line++
+			e.Column = 1
+		} else {
+			e.Column++
+		}
+	}
+	return e
+}
+
+// expectedErrorf returns an error listing the tokens that would have been valid at the current position
+func expectedErrorf(expected []string, format string, a ...any) error {
+	return &ParseError{Message: fmt.Sprintf(format, a...), Expected: expected}
+}
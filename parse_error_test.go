@@ -0,0 +1,104 @@
+package sqlparser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		expected ParseError
+	}{
+		{
+			name: "missing FROM",
+			sql:  "SELECT a, b 'users'",
+			expected: ParseError{
+				Message:  "at SELECT: expected comma or FROM",
+				Offset:   12,
+				Line:     1,
+				Column:   13,
+				Token:    "'users'",
+				Expected: []string{",", "FROM"},
+			},
+		},
+		{
+			name: "position in the original SQL on a later line",
+			sql:  "\n\n  SELECT a FROM 'users' WHERE a é 'x'",
+			expected: ParseError{
+				Message:  "at WHERE: unknown operator",
+				Offset:   34,
+				Line:     3,
+				Column:   33,
+				Token:    "é",
+				Expected: conditionOperators,
+			},
+		},
+		{
+			name: "wrapped error keeps expected tokens",
+			sql:  "SELECT a FROM 'users' LIMIT x",
+			expected: ParseError{
+				Message:  "at LIMIT: expected non-negative number",
+				Offset:   28,
+				Line:     1,
+				Column:   29,
+				Token:    "x",
+				Expected: []string{"number"},
+			},
+		},
+		{
+			name: "empty column list",
+			sql:  "CREATE TABLE t ()",
+			expected: ParseError{
+				Message:  "at CREATE TABLE: expected column name",
+				Offset:   16,
+				Line:     1,
+				Column:   17,
+				Token:    ")",
+				Expected: []string{"column"},
+			},
+		},
+		{
+			name: "validation error at the end of the query",
+			sql:  "DELETE FROM 'users'",
+			expected: ParseError{
+				Message: "at WHERE: WHERE clause is mandatory for UPDATE & DELETE",
+				Offset:  19,
+				Line:    1,
+				Column:  20,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.sql)
+			var parseErr *ParseError
+			require.True(t, errors.As(err, &parseErr))
+			tt.expected.SQL = tt.sql
+			require.Equal(t, &tt.expected, parseErr)
+			require.EqualError(t, err, tt.expected.Message)
+		})
+	}
+}
+
+func TestParseErrorFormat(t *testing.T) {
+	_, err := Parse("\nSELECT a FROM 'users' WHERE a é 'x'")
+	require.Equal(t, "at WHERE: unknown operator", fmt.Sprint(err))
+	require.Equal(t, "at WHERE: unknown operator   |", fmt.Sprintf("%-29s|", err))
+	require.Equal(t, `   "at WHERE"`, fmt.Sprintf("%13.8q", err))
+	require.Equal(t, "SELECT a FROM 'users' WHERE a é 'x'\n"+strings.Repeat(" ", 30)+"^\nat WHERE: unknown operator (expected \"=\" or \"!=\" or \">\" or \">=\" or \"<\" or \"<=\" or \"LIKE\" or \"NOT LIKE\" or \"IN\" or \"NOT IN\" or \"BETWEEN\" or \"NOT BETWEEN\" or \"IS\")", fmt.Sprintf("%+v", err))
+
+	var parseErr *ParseError
+	require.True(t, errors.As(err, &parseErr))
+	require.Equal(t, fmt.Sprintf("%+v", err), parseErr.Diagram())
+
+	// Tabs are kept in the padding of the caret
+	_, err = Parse("SELECT a\n\tFROM 'b'\n\tWHERE\ta ? 1")
+	require.True(t, errors.As(err, &parseErr))
+	require.Equal(t, "\tWHERE\ta ? 1\n\t     \t  ^\nat WHERE: unknown operator", strings.SplitN(parseErr.Diagram(), " (expected", 2)[0])
+}
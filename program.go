@@ -0,0 +1,551 @@
+package sqlparser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Program is a SELECT query compiled for matching rows: field paths are split once, literals are
+// prepared for comparison and LIKE patterns are compiled once. A Program never changes once compiled
+// and is safe for concurrent use by multiple goroutines.
+type Program struct {
+	query  Query
+	where  *expression
+	having *expression
+	// fields are the computed fields of the SELECT list by their text, which ORDER BY may refer to
+	fields map[string]valueFunc
+}
+
+// Compile parses a SELECT query and compiles its WHERE clause, so that it can be matched against many
+// rows without parsing it again. It may fail.
+func Compile(sql string) (*Program, error) {
+	q, err := Parse(sql)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SQL: %w", err)
+	}
+	return CompileQuery(q)
+}
+
+// CompileQuery compiles the WHERE and HAVING clauses and the computed fields of a parsed SELECT query,
+// e.g. one with Strict set. It may fail.
+func CompileQuery(q Query) (*Program, error) {
+	if q.Type != Select {
+		return nil, fmt.Errorf("only SELECT queries can be filtered")
+	}
+	fields := make(map[string]valueFunc, len(q.FieldExprs))
+	for field, expr := range q.FieldExprs {
+		fields[field] = compileValueExpr(expr, q.Strict)
+	}
+	return &Program{
+		query:  q,
+		where:  compileExpr(q.where(), q.Strict),
+		having: compileExpr(q.Having, q.Strict),
+		fields: fields,
+	}, nil
+}
+
+// Query returns the parsed query of the program
+func (p *Program) Query() Query {
+	return p.query
+}
+
+// Match reports whether a row matches the WHERE clause. Rows for which the clause is unknown, such as
+// comparisons with missing fields, don't match.
+func (p *Program) Match(row map[string]any) bool {
+	return p.where.matches(row)
+}
+
+// Filter returns the rows matching the WHERE clause, keyed as in rows, see FilterRecursive
+func (p *Program) Filter(rows map[string]map[string]any) map[string]map[string]any {
+	filtered := make(map[string]map[string]any)
+	for key, row := range rows {
+		if p.where.matches(row) {
+			filtered[key] = row
+		}
+	}
+	return filtered
+}
+
+// truth is the result of a condition in SQL's three-valued logic: comparing NULL, or a missing field,
+// with anything is neither true nor false but unknown
+type truth int
+
+const (
+	unknown truth = iota
+	isFalse
+	isTrue
+)
+
+func truthOf(b bool) truth {
+	if b {
+		return isTrue
+	}
+	return isFalse
+}
+
+func (t truth) and(other truth) truth {
+	switch {
+	case t == isFalse || other == isFalse:
+		return isFalse
+	case t == unknown || other == unknown:
+		return unknown
+	default:
+		return isTrue
+	}
+}
+
+func (t truth) or(other truth) truth {
+	switch {
+	case t == isTrue || other == isTrue:
+		return isTrue
+	case t == unknown || other == unknown:
+		return unknown
+	default:
+		return isFalse
+	}
+}
+
+func (t truth) not() truth {
+	switch t {
+	case isTrue:
+		return isFalse
+	case isFalse:
+		return isTrue
+	default:
+		return unknown
+	}
+}
+
+// expression is a compiled WHERE or HAVING expression: its conditions, and instructions combining their
+// results on a stack, evaluated in a loop
+type expression struct {
+	conditions []condition
+	code       []instruction
+	strict     bool
+}
+
+// opcode is the operation of an instruction
+type opcode int
+
+const (
+	// opCondition pushes the result of a condition
+	opCondition opcode = iota
+	// opAnd pops two results and pushes their AND
+	opAnd
+	// opOr pops two results and pushes their OR
+	opOr
+	// opNot negates the top result
+	opNot
+	// opJumpIfFalse jumps to the target when the top result is false, which is then the result of an AND
+	opJumpIfFalse
+	// opJumpIfTrue jumps to the target when the top result is true, which is then the result of an OR
+	opJumpIfTrue
+)
+
+// instruction is a step of an expression. arg is the index of the condition of opCondition and the
+// target of jumps.
+type instruction struct {
+	op  opcode
+	arg int
+}
+
+// maxStackDepth is the stack depth of an expression evaluated without allocating
+const maxStackDepth = 32
+
+// matches reports whether the expression is true for a row
+func (e *expression) matches(row map[string]any) bool {
+	return e.eval(row) == isTrue
+}
+
+// eval evaluates the expression against a row. A nil expression matches every row
+func (e *expression) eval(row map[string]any) truth {
+	if len(e.code) == 0 {
+		return isTrue
+	}
+	var buffer [maxStackDepth]truth
+	stack := buffer[:0]
+	for pc := 0; pc < len(e.code); pc++ {
+		instruction := e.code[pc]
+		switch instruction.op {
+		case opCondition:
+			stack = append(stack, e.conditions[instruction.arg].eval(row))
+		case opAnd:
+			stack[len(stack)-2] = stack[len(stack)-2].and(stack[len(stack)-1])
+			stack = stack[:len(stack)-1]
+		case opOr:
+			stack[len(stack)-2] = stack[len(stack)-2].or(stack[len(stack)-1])
+			stack = stack[:len(stack)-1]
+		case opNot:
+			stack[len(stack)-1] = stack[len(stack)-1].not()
+		case opJumpIfFalse:
+			if stack[len(stack)-1] == isFalse {
+				pc = instruction.arg - 1
+			}
+		case opJumpIfTrue:
+			if stack[len(stack)-1] == isTrue {
+				pc = instruction.arg - 1
+			}
+		}
+	}
+	return stack[0]
+}
+
+// compileExpr compiles a WHERE or HAVING expression tree. Strict expressions only compare values of the
+// same type, see Query.Strict.
+func compileExpr(expr Expr, strict bool) *expression {
+	e := &expression{strict: strict}
+	e.compile(expr)
+	return e
+}
+
+// compile appends the instructions of an expression tree, leaving its result on the stack. AND and OR
+// jump over their right operand when the left one decides the result.
+func (e *expression) compile(expr Expr) {
+	switch expr := expr.(type) {
+	case Condition:
+		e.conditions = append(e.conditions, compileCondition(expr, e.strict))
+		e.code = append(e.code, instruction{op: opCondition, arg: len(e.conditions) - 1})
+	case *AndExpr:
+		e.compileJunction(expr.Left, expr.Right, opJumpIfFalse, opAnd)
+	case *OrExpr:
+		e.compileJunction(expr.Left, expr.Right, opJumpIfTrue, opOr)
+	case *NotExpr:
+		e.compile(expr.Expr)
+		e.code = append(e.code, instruction{op: opNot})
+	case *ParenExpr:
+		e.compile(expr.Expr)
+	}
+}
+
+func (e *expression) compileJunction(left, right Expr, jump, combine opcode) {
+	e.compile(left)
+	jumpIndex := len(e.code)
+	e.code = append(e.code, instruction{op: jump})
+	e.compile(right)
+	e.code = append(e.code, instruction{op: combine})
+	e.code[jumpIndex].arg = len(e.code)
+}
+
+// condition is a compiled Condition. Either operand may be a field, a literal or an expression, and a
+// missing field is NULL: only IS NULL and IS NOT NULL are known for a NULL value. An expression that
+// can't be computed, such as a division by zero, is NULL too.
+type condition struct {
+	operator Operator
+	// field1 is the left operand when it is a field, expr1 when it is an expression, and value1 and
+	// literal1 when it is a literal
+	field1   *fieldPath
+	expr1    valueFunc
+	value1   any
+	literal1 literal
+	// field2 is the right operand or the lower bound of BETWEEN when it is a field, expr2 when it is an
+	// expression, and literal2 when it is a literal
+	field2   *fieldPath
+	expr2    valueFunc
+	literal2 literal
+	// field3, expr3 and literal3 are the upper bound of BETWEEN
+	field3   *fieldPath
+	expr3    valueFunc
+	literal3 literal
+	in       *valueSet
+	pattern  string
+	strict   bool
+}
+
+func compileCondition(cond Condition, strict bool) condition {
+	c := condition{
+		operator: cond.Operator,
+		strict:   strict,
+		value1:   cond.Operand1Value.Any(),
+		literal1: newLiteral(cond.Operand1Value),
+		literal2: newLiteral(cond.Operand2Value),
+		literal3: newLiteral(cond.Operand3Value),
+		pattern:  cond.Operand2Value.Str,
+	}
+	if cond.Operand1Expr != nil {
+		c.expr1 = compileValueExpr(cond.Operand1Expr, strict)
+	}
+	if cond.Operand2Expr != nil {
+		c.expr2 = compileValueExpr(cond.Operand2Expr, strict)
+	}
+	if cond.Operand3Expr != nil {
+		c.expr3 = compileValueExpr(cond.Operand3Expr, strict)
+	}
+	if cond.Operand1IsField {
+		c.field1 = newFieldPath(cond.Operand1)
+	}
+	if cond.Operand2IsField {
+		c.field2 = newFieldPath(cond.Operand2)
+	}
+	if cond.Operand3IsField {
+		c.field3 = newFieldPath(cond.Operand3)
+	}
+	if cond.Operator == In || cond.Operator == NotIn {
+		c.in = newValueSet(cond.InValues)
+	}
+	return c
+}
+
+func (c *condition) eval(row map[string]any) truth {
+	value := c.value1
+	switch {
+	case c.field1 != nil:
+		value, _ = c.field1.get(row)
+	case c.expr1 != nil:
+		value = c.expr1.operand(row)
+	}
+	switch c.operator {
+	case IsNull:
+		return truthOf(value == nil)
+	case IsNotNull:
+		return truthOf(value != nil)
+	}
+	if value == nil {
+		return unknown
+	}
+
+	switch c.operator {
+	case Eq, Ne, Gt, Gte, Lt, Lte:
+		return c.compare(row, value, c.operator, c.field2, c.expr2, c.literal2)
+	case Like:
+		s, ok := value.(string)
+		return truthOf(ok && matchLike(s, c.pattern))
+	case NotLike:
+		s, ok := value.(string)
+		return truthOf(!ok || !matchLike(s, c.pattern))
+	case In:
+		return c.in.contains(value, c.strict)
+	case NotIn:
+		return c.in.contains(value, c.strict).not()
+	case Between:
+		// BETWEEN is value >= Operand2 AND value <= Operand3
+		return c.compare(row, value, Gte, c.field2, c.expr2, c.literal2).and(c.compare(row, value, Lte, c.field3, c.expr3, c.literal3))
+	case NotBetween:
+		return c.compare(row, value, Gte, c.field2, c.expr2, c.literal2).and(c.compare(row, value, Lte, c.field3, c.expr3, c.literal3)).not()
+	default:
+		return isFalse
+	}
+}
+
+// compare compares the left operand value with a right operand, which is a field, an expression or a
+// literal
+func (c *condition) compare(row map[string]any, value any, operator Operator, field *fieldPath, expr valueFunc, lit literal) truth {
+	if field == nil && expr == nil {
+		return compareLiteral(value, lit, operator, c.strict)
+	}
+	var other any
+	if field != nil {
+		other, _ = field.get(row)
+	} else {
+		other = expr.operand(row)
+	}
+	return compareFieldOperand(value, other, c.field1 != nil || c.expr1 != nil, c.literal1, operator, c.strict)
+}
+
+// fieldPath is a field name split on its dots once, see getFieldValue
+type fieldPath struct {
+	name  string
+	parts []string
+}
+
+func newFieldPath(name string) *fieldPath {
+	return &fieldPath{name: name, parts: strings.Split(name, ".")}
+}
+
+// get looks the field up by its exact name first, and then as a dot separated path into nested maps
+func (f *fieldPath) get(row map[string]any) (any, bool) {
+	if value, exists := row[f.name]; exists {
+		return value, true
+	}
+	var value any = row
+	for _, part := range f.parts {
+		nested, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = nested[part]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// compareLiteral compares a value with a literal. Comparing with NULL is unknown, values that can't be
+// compared with the literal, or that don't have its type when strict, are only not equal.
+func compareLiteral(value any, right literal, operator Operator, strict bool) truth {
+	if right.Kind == NullValue {
+		return unknown
+	}
+	cmp, ok := right.compare(value)
+	if !ok || strict && !sameType(value, right.Kind) {
+		return truthOf(operator == Ne)
+	}
+	return compareResult(cmp, operator)
+}
+
+// compareFieldOperand compares a value with the value of the field on the right of a condition. A
+// literal on the left compares with the field as it would on the right. Values of different types are
+// only not equal when strict.
+func compareFieldOperand(value, other any, leftIsField bool, left literal, operator Operator, strict bool) truth {
+	if other == nil {
+		return unknown
+	}
+	if strict && !sameType(value, valueOf(other).Kind) {
+		return truthOf(operator == Ne)
+	}
+	var cmp int
+	var ok bool
+	if leftIsField {
+		cmp, ok = compareFieldValues(value, other)
+	} else {
+		cmp, ok = left.compare(other)
+		cmp = -cmp
+	}
+	if !ok {
+		return truthOf(operator == Ne)
+	}
+	return compareResult(cmp, operator)
+}
+
+// compareResult applies a comparison operator to the result of a comparison
+func compareResult(cmp int, operator Operator) truth {
+	switch operator {
+	case Eq:
+		return truthOf(cmp == 0)
+	case Ne:
+		return truthOf(cmp != 0)
+	case Gt:
+		return truthOf(cmp > 0)
+	case Gte:
+		return truthOf(cmp >= 0)
+	case Lt:
+		return truthOf(cmp < 0)
+	case Lte:
+		return truthOf(cmp <= 0)
+	default:
+		return isFalse
+	}
+}
+
+// valueSet is the list of an IN condition as hash sets, matching values as compareValue would with each
+// literal of the list
+type valueSet struct {
+	// strings holds the string literals, and numericStrings those that are numbers too
+	strings        map[string]bool
+	numericStrings map[float64]bool
+	// numbers holds the numeric literals
+	numbers map[float64]bool
+	bools   [2]bool
+	hasNull bool
+}
+
+func newValueSet(values []Value) *valueSet {
+	s := &valueSet{strings: map[string]bool{}, numericStrings: map[float64]bool{}, numbers: map[float64]bool{}}
+	for _, value := range values {
+		l := newLiteral(value)
+		switch value.Kind {
+		case StringValue:
+			s.strings[value.Str] = true
+			if l.numeric {
+				s.numericStrings[l.number] = true
+			}
+		case IntValue, FloatValue:
+			s.numbers[l.number] = true
+		case BoolValue:
+			s.bools[boolIndex(value.Bool)] = true
+		default:
+			s.hasNull = true
+		}
+	}
+	return s
+}
+
+// contains reports whether the set contains a value, only of the same type when strict. Without a
+// match, a NULL in the list makes the result unknown.
+func (s *valueSet) contains(value any, strict bool) truth {
+	if s.containsValue(value, strict) {
+		return isTrue
+	}
+	if s.hasNull {
+		return unknown
+	}
+	return isFalse
+}
+
+func (s *valueSet) containsValue(value any, strict bool) bool {
+	switch v := value.(type) {
+	case string:
+		if s.strings[v] {
+			return true
+		}
+		if strict {
+			return false
+		}
+		if len(s.numbers) > 0 {
+			if number, err := strconv.ParseFloat(v, 64); err == nil && s.numbers[number] {
+				return true
+			}
+		}
+		if s.bools != [2]bool{} {
+			if b, err := strconv.ParseBool(v); err == nil && s.bools[boolIndex(b)] {
+				return true
+			}
+		}
+		return false
+	case bool:
+		return s.bools[boolIndex(v)] || !strict && len(s.strings) > 0 && s.strings[strconv.FormatBool(v)]
+	}
+	if number, ok := toFloat64(value); ok {
+		return s.numbers[number] || !strict && s.numericStrings[number]
+	}
+	return len(s.strings) > 0 && s.strings[fmt.Sprintf("%v", value)]
+}
+
+func boolIndex(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// matchLike reports whether s matches a LIKE pattern, in which % matches any sequence of characters and
+// _ matches a single character. On a mismatch it only backtracks to the last %, so it takes at most
+// len(s) * len(pattern) steps and never allocates.
+func matchLike(s, pattern string) bool {
+	i, j := 0, 0
+	// star is the position in pattern after the last %, and next the position in s it is retried at
+	star, next := -1, 0
+	for i < len(s) {
+		if j < len(pattern) {
+			switch pattern[j] {
+			case '%':
+				j++
+				star, next = j, i
+				continue
+			case '_':
+				_, size := utf8.DecodeRuneInString(s[i:])
+				i += size
+				j++
+				continue
+			default:
+				if s[i] == pattern[j] {
+					i++
+					j++
+					continue
+				}
+			}
+		}
+		if star < 0 {
+			return false
+		}
+		// Let the last % match one more character
+		_, size := utf8.DecodeRuneInString(s[next:])
+		next += size
+		i, j = next, star
+	}
+	for j < len(pattern) && pattern[j] == '%' {
+		j++
+	}
+	return j == len(pattern)
+}
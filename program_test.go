@@ -0,0 +1,247 @@
+package sqlparser
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		sql         string
+		expectedErr string
+	}{
+		{sql: "SELECT * FROM t WHERE a = 1"},
+		{sql: "SELECT * FROM t"},
+		{sql: "SELECT * FROM t WHERE", expectedErr: "failed to parse SQL: at WHERE: empty WHERE clause"},
+		{sql: "DELETE FROM t WHERE a = 1", expectedErr: "only SELECT queries can be filtered"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			program, err := Compile(tt.sql)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				require.Nil(t, program)
+				return
+			}
+			require.NoError(t, err)
+			query, err := Parse(tt.sql)
+			require.NoError(t, err)
+			require.Equal(t, query, program.Query())
+		})
+	}
+}
+
+func TestProgramMatch(t *testing.T) {
+	program, err := Compile("SELECT * FROM t WHERE (device.name LIKE 'sensor_%' OR device.name = 'probe') AND temp > '20.5' AND level IN (1, 2) AND NOT state NOT LIKE '%on%'")
+	require.NoError(t, err)
+
+	tests := []struct {
+		row      map[string]any
+		expected bool
+	}{
+		{row: map[string]any{"device": map[string]any{"name": "sensor_1"}, "temp": 21, "level": "2", "state": "on"}, expected: true},
+		{row: map[string]any{"device": map[string]any{"name": "probe"}, "temp": "30", "level": 1, "state": "turned on"}, expected: true},
+		{row: map[string]any{"device.name": "sensor.b", "temp": 21.5, "level": 1.0, "state": "on"}, expected: true},
+		{row: map[string]any{"device": map[string]any{"name": "sensor"}, "temp": 21, "level": 1, "state": "on"}, expected: false},
+		{row: map[string]any{"device": map[string]any{"name": "probe"}, "temp": 20, "level": 1, "state": "on"}, expected: false},
+		{row: map[string]any{"device": map[string]any{"name": "probe"}, "temp": 21, "level": 3, "state": "on"}, expected: false},
+		{row: map[string]any{"device": map[string]any{"name": "probe"}, "temp": 21, "level": 1, "state": "off"}, expected: false},
+		{row: map[string]any{"device": map[string]any{"name": "probe"}, "temp": 21, "level": 1}, expected: false},
+		{row: map[string]any{}, expected: false},
+	}
+	for i, tt := range tests {
+		require.Equal(t, tt.expected, program.Match(tt.row), "row %d", i)
+	}
+}
+
+func TestCompileQueryStrict(t *testing.T) {
+	rows := []map[string]any{
+		{"n": "10", "b": "true"},
+		{"n": 9, "b": true},
+		{"n": 9.5, "b": "x"},
+	}
+	tests := []struct {
+		where    string
+		lenient  []int
+		expected []int
+	}{
+		{where: "n > 9", lenient: []int{0, 2}, expected: []int{2}},
+		{where: "n = '10'", lenient: []int{0}, expected: []int{0}},
+		{where: "n < '9.5'", lenient: []int{0, 1}, expected: []int{0}},
+		{where: "n IN (9, 10)", lenient: []int{0, 1}, expected: []int{1}},
+		{where: "n BETWEEN 9 AND 10", lenient: []int{0, 1, 2}, expected: []int{1, 2}},
+		{where: "n != 10", lenient: []int{1, 2}, expected: []int{0, 1, 2}},
+		{where: "b = TRUE", lenient: []int{0, 1}, expected: []int{1}},
+		{where: "n + 1 > 10", lenient: []int{0, 2}, expected: []int{2}},
+		{where: "n = b", lenient: []int{}, expected: []int{}},
+		{where: "b::BOOL = TRUE", lenient: []int{0, 1}, expected: []int{0, 1}},
+		{where: "CAST(n AS INT) >= 10", lenient: []int{0}, expected: []int{0}},
+		{where: "n::FLOAT > 9", lenient: []int{0, 2}, expected: []int{0, 2}},
+		{where: "CASE n WHEN 9 THEN 'nine' END = 'nine'", lenient: []int{1}, expected: []int{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.where, func(t *testing.T) {
+			q, err := Parse("SELECT * FROM t WHERE " + tt.where)
+			require.NoError(t, err)
+			for _, strict := range []bool{false, true} {
+				q.Strict = strict
+				program, err := CompileQuery(q)
+				require.NoError(t, err)
+				matched := []int{}
+				for i, row := range rows {
+					if program.Match(row) {
+						matched = append(matched, i)
+					}
+				}
+				expected := tt.lenient
+				if strict {
+					expected = tt.expected
+				}
+				require.Equal(t, expected, matched, "strict %v", strict)
+			}
+		})
+	}
+}
+
+func TestProgramFilterConcurrently(t *testing.T) {
+	program, err := Compile("SELECT * FROM t WHERE temp BETWEEN 10 AND 19 AND name LIKE 'd%'")
+	require.NoError(t, err)
+
+	rows := map[string]map[string]any{}
+	expected := map[string]map[string]any{}
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprint(i)
+		rows[key] = map[string]any{"temp": i, "name": "d" + key}
+		if i >= 10 && i <= 19 {
+			expected[key] = rows[key]
+		}
+	}
+
+	var wg sync.WaitGroup
+	results := make([]map[string]map[string]any, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = program.Filter(rows)
+		}(i)
+	}
+	wg.Wait()
+	for _, result := range results {
+		require.Equal(t, expected, result)
+	}
+}
+
+func benchmarkMatch(b *testing.B, sql string, row map[string]any, expected bool) {
+	program, err := Compile(sql)
+	require.NoError(b, err)
+	require.Equal(b, expected, program.Match(row))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		program.Match(row)
+	}
+}
+
+func BenchmarkProgramMatch(b *testing.B) {
+	row := map[string]any{"device": map[string]any{"name": "sensor_12"}, "temp": "31.5", "state": "on", "level": 2}
+	benchmarkMatch(b, "SELECT * FROM t WHERE device.name LIKE 'sensor%' AND temp > 30 AND state != 'off' AND level IN (1, 2, 3)", row, true)
+}
+
+func BenchmarkProgramMatchLargeIn(b *testing.B) {
+	values := make([]string, 10000)
+	for i := range values {
+		values[i] = fmt.Sprintf("'device-%d'", i)
+	}
+	sql := "SELECT * FROM t WHERE id IN (" + strings.Join(values, ", ") + ")"
+	benchmarkMatch(b, sql, map[string]any{"id": "device-9999"}, true)
+}
+
+func BenchmarkProgramMatchLongLike(b *testing.B) {
+	pattern := strings.Repeat("%a_b", 200) + "%"
+	value := strings.Repeat("xxaxbyy", 200)
+	benchmarkMatch(b, "SELECT * FROM t WHERE name LIKE '"+pattern+"'", map[string]any{"name": value}, true)
+}
+
+func BenchmarkProgramMatchManyConditions(b *testing.B) {
+	conditions := make([]string, 1000)
+	row := map[string]any{}
+	for i := range conditions {
+		conditions[i] = fmt.Sprintf("f%d = %d", i, i)
+		row[fmt.Sprintf("f%d", i)] = i
+	}
+	benchmarkMatch(b, "SELECT * FROM t WHERE "+strings.Join(conditions, " AND "), row, true)
+}
+
+func BenchmarkFilterRecursive(b *testing.B) {
+	rows := map[string]map[string]any{}
+	for i := 0; i < 1000; i++ {
+		rows[fmt.Sprint(i)] = map[string]any{"temp": i, "name": fmt.Sprintf("sensor-%d", i)}
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := FilterRecursive("SELECT * FROM t WHERE temp BETWEEN 100 AND 200 AND name LIKE 'sensor-1%'", rows)
+		require.NoError(b, err)
+	}
+}
+
+func TestMatchLike(t *testing.T) {
+	tests := []struct {
+		s, pattern string
+		expected   bool
+	}{
+		{s: "", pattern: "", expected: true},
+		{s: "", pattern: "%", expected: true},
+		{s: "", pattern: "_", expected: false},
+		{s: "abc", pattern: "abc", expected: true},
+		{s: "abc", pattern: "ab", expected: false},
+		{s: "abc", pattern: "a%", expected: true},
+		{s: "abc", pattern: "%c", expected: true},
+		{s: "abc", pattern: "%b%", expected: true},
+		{s: "abc", pattern: "a_c", expected: true},
+		{s: "abc", pattern: "a__c", expected: false},
+		{s: "abcbd", pattern: "%b_", expected: true},
+		{s: "aXbXbXc", pattern: "a%b%c", expected: true},
+		{s: "aXbXbX", pattern: "a%b%c", expected: false},
+		{s: "mississippi", pattern: "%issip%i", expected: true},
+		{s: "a.c", pattern: "a.c", expected: true},
+		{s: "abc", pattern: "a.c", expected: false},
+		{s: "a+(b)*", pattern: "a+(b)*", expected: true},
+		{s: "é", pattern: "_", expected: true},
+		{s: "née", pattern: "n_e", expected: true},
+		{s: "née", pattern: "%é_", expected: true},
+		{s: "nêe", pattern: "né%", expected: false},
+		{s: "line\nbreak", pattern: "line_break", expected: true},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, matchLike(tt.s, tt.pattern), "%q LIKE %q", tt.s, tt.pattern)
+	}
+}
+
+func TestValueSetContains(t *testing.T) {
+	literals := []Value{NewString("a"), NewString("10"), NewString("true"), NewInt(2), NewFloat(2.5), NewBool(false), {}}
+	values := []any{"a", "b", "10", "10.0", "2", "2.50", "true", "false", "0", 10, 10.0, 2, int64(2), 2.5, float32(3), true, false, nil, []int{1}}
+	// A set must match a value exactly as comparing it with each of its literals would
+	for _, strict := range []bool{false, true} {
+		for i := range literals {
+			for j := i; j <= len(literals); j++ {
+				list := literals[i:j]
+				set := newValueSet(list)
+				for _, value := range values {
+					expected := false
+					for _, literal := range list {
+						if cmp, ok := compareValue(value, literal); ok && cmp == 0 && (!strict || sameType(value, literal.Kind)) {
+							expected = true
+						}
+					}
+					require.Equal(t, expected, set.containsValue(value, strict), "%#v IN %v, strict %v", value, list, strict)
+				}
+			}
+		}
+	}
+}
@@ -7,28 +7,194 @@ import (
 
 // Query represents a parsed query
 type Query struct {
-	Type         Type
-	TableName    string
-	Conditions   []Condition
-	Updates      map[string]string
-	Inserts      [][]string
-	Fields       []string // Used for SELECT (i.e. SELECTed field names) and INSERT (INSERTEDed field names)
-	Aliases      map[string]string
-	CreateFields map[string]string // name1 type, name2 type ...
+	Type        Type
+	TableName   string
+	Conditions  []Condition          // Compatibility view of Where when it is a pure AND chain of conditions
+	Where       Expr                 // Boolean expression tree of the WHERE clause
+	Assignments []Assignment         // Used for UPDATE, SET assignments in order
+	Inserts     [][]ValueExpr        // Used for INSERT, rows of values, computed when the query runs
+	Fields      []string             // Used for SELECT (i.e. SELECTed field names) and INSERT (INSERTEDed field names)
+	FieldExprs  map[string]ValueExpr // Used for SELECT, computed fields such as "a * b" by their text in Fields
+	Aliases     map[string]string
+	Columns     []Column          // Used for CREATE TABLE, column definitions in order
+	Constraints []TableConstraint // Used for CREATE TABLE, table constraints in order
+	IfNotExists bool              // Used for CREATE TABLE IF NOT EXISTS
+	IfExists    bool              // Used for DROP TABLE IF EXISTS
+	Alter       *AlterTable       // Used for ALTER TABLE, the altering action
+	GroupBy     []string          // Used for SELECT, GROUP BY field names
+	Having      Expr              // Used for SELECT, boolean expression tree of the HAVING clause
+	Aggregates  []Aggregate       // Aggregate function calls used in the SELECT, HAVING and ORDER BY clauses
+	OrderBy     []OrderBy         // Used for SELECT, in ORDER BY order
+	Limit       int               // Used for SELECT when HasLimit is set
+	HasLimit    bool              // Determines if the SELECT has a LIMIT
+	Offset      int               // Used for SELECT, number of rows to skip
+	QuotedNames map[string]bool   // Table and field names written as quoted identifiers, quoted again by String()
+	// Strict disables implicit type conversions when the query runs: comparisons only match values of
+	// the same type, and arithmetic doesn't take numeric strings as numbers. Values are converted
+	// explicitly with CAST. It is not part of the SQL and is set by callers, see CompileQuery.
+	Strict bool
+}
+
+// Assignment is a single "field = value" of the SET clause of an UPDATE query
+type Assignment struct {
+	Field string
+	Value ValueExpr
+}
+
+// Updates returns the assigned values of an UPDATE query by field name, see Assignments
+func (q Query) Updates() map[string]ValueExpr {
+	updates := make(map[string]ValueExpr, len(q.Assignments))
+	for _, assignment := range q.Assignments {
+		updates[assignment.Field] = assignment.Value
+	}
+	return updates
+}
+
+// Column is a column definition of a CREATE TABLE query
+type Column struct {
+	Name string
+	// Type is the type name as written, e.g. VARCHAR
+	Type string
+	// TypeParams are the parameters of the type as written, e.g. 10 and 2 for DECIMAL(10, 2)
+	TypeParams []string
+	PrimaryKey bool
+	NotNull    bool
+	Unique     bool
+	// Default is the expression of the DEFAULT value, e.g. a literal or NOW(), nil without one
+	Default ValueExpr
+	// Check is the expression of a CHECK constraint, nil without one
+	Check Expr
+	// References is set by a REFERENCES constraint
+	References *Reference
+}
+
+// AlterAction is the action of an ALTER TABLE query
+type AlterAction int
+
+const (
+	// UnknownAlterAction is the zero value for an AlterAction
+	UnknownAlterAction AlterAction = iota
+	// AddColumn -> "ADD COLUMN a INT"
+	AddColumn
+	// DropColumn -> "DROP COLUMN a"
+	DropColumn
+	// RenameColumn -> "RENAME COLUMN a TO b"
+	RenameColumn
+	// RenameTable -> "RENAME TO b"
+	RenameTable
+	// AlterColumnType -> "ALTER COLUMN a TYPE INT"
+	AlterColumnType
+)
+
+// AlterActionString is a string slice with the names of all alter actions in order
+var AlterActionString = []string{
+	"UnknownAlterAction",
+	"AddColumn",
+	"DropColumn",
+	"RenameColumn",
+	"RenameTable",
+	"AlterColumnType",
+}
+
+// AlterTable is the action of an ALTER TABLE query
+type AlterTable struct {
+	Action AlterAction
+	// Column is the added column for AddColumn, the column and its new type for AlterColumnType, and
+	// only names the column for DropColumn and RenameColumn
+	Column Column
+	// NewName is the new column name for RenameColumn and the new table name for RenameTable
+	NewName string
+}
+
+// Reference is the table and columns referenced by a foreign key
+type Reference struct {
+	Table   string
+	Columns []string
+}
+
+// ConstraintType is the type of a table constraint in CREATE TABLE
+type ConstraintType int
+
+const (
+	// UnknownConstraint is the zero value for a ConstraintType
+	UnknownConstraint ConstraintType = iota
+	// PrimaryKeyConstraint -> "PRIMARY KEY (a, b)"
+	PrimaryKeyConstraint
+	// UniqueConstraint -> "UNIQUE (a, b)"
+	UniqueConstraint
+	// CheckConstraint -> "CHECK (a > 0)"
+	CheckConstraint
+	// ForeignKeyConstraint -> "FOREIGN KEY (a) REFERENCES b (c)"
+	ForeignKeyConstraint
+)
+
+// ConstraintTypeString is a string slice with the names of all constraint types in order
+var ConstraintTypeString = []string{
+	"UnknownConstraint",
+	"PrimaryKeyConstraint",
+	"UniqueConstraint",
+	"CheckConstraint",
+	"ForeignKeyConstraint",
+}
+
+// TableConstraint is a constraint of CREATE TABLE written after the columns
+type TableConstraint struct {
+	Type ConstraintType
+	// Name is set by CONSTRAINT name
+	Name string
+	// Columns are the constrained columns of PRIMARY KEY, UNIQUE and FOREIGN KEY
+	Columns []string
+	// Check is the expression of CHECK
+	Check Expr
+	// References is the referenced table and columns of FOREIGN KEY
+	References *Reference
+}
+
+// TypeWithParams returns the type with its parameters, e.g. VARCHAR(255)
+func (c Column) TypeWithParams() string {
+	if len(c.TypeParams) == 0 {
+		return c.Type
+	}
+	return c.Type + "(" + strings.Join(c.TypeParams, ", ") + ")"
+}
+
+// CreateFields returns the column types of a CREATE TABLE query by column name, see Columns
+func (q Query) CreateFields() map[string]string {
+	fields := make(map[string]string, len(q.Columns))
+	for _, column := range q.Columns {
+		fields[column.Name] = column.TypeWithParams()
+	}
+	return fields
+}
+
+// OrderBy is a single sort key of an ORDER BY clause
+type OrderBy struct {
+	Field string
+	Desc  bool
+}
+
+func (o OrderBy) String() string {
+	if o.Desc {
+		return o.Field + " DESC"
+	}
+	return o.Field
 }
 
 func (q Query) String() string {
 	var sb strings.Builder
+	name := func(name string) string {
+		return quoteName(name, q.QuotedNames[name])
+	}
 
 	switch q.Type {
 	case Select:
 		sb.WriteString("SELECT ")
 		if len(q.Fields) > 0 {
 			for i, field := range q.Fields {
-				sb.WriteString(field)
+				sb.WriteString(name(field))
 				if alias, ok := q.Aliases[field]; ok {
 					sb.WriteString(" AS ")
-					sb.WriteString(alias)
+					sb.WriteString(name(alias))
 				}
 				if i < len(q.Fields)-1 {
 					sb.WriteString(", ")
@@ -38,91 +204,262 @@ func (q Query) String() string {
 			sb.WriteString("*")
 		}
 		sb.WriteString(" FROM ")
-		sb.WriteString(q.TableName)
+		sb.WriteString(q.tableName(q.TableName))
 	case Insert:
 		sb.WriteString("INSERT INTO ")
-		sb.WriteString(q.TableName)
+		sb.WriteString(q.tableName(q.TableName))
 		sb.WriteString(" (")
-		sb.WriteString(strings.Join(q.Fields, ", "))
+		for i, field := range q.Fields {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(name(field))
+		}
 		sb.WriteString(") VALUES ")
 		for i, row := range q.Inserts {
-			sb.WriteString("('")
-			sb.WriteString(strings.Join(row, "', '"))
-			sb.WriteString("')")
+			sb.WriteString("(")
+			for j, value := range row {
+				if j > 0 {
+					sb.WriteString(", ")
+				}
+				sb.WriteString(value.String())
+			}
+			sb.WriteString(")")
 			if i < len(q.Inserts)-1 {
 				sb.WriteString(", ")
 			}
 		}
 	case Update:
 		sb.WriteString("UPDATE ")
-		sb.WriteString(q.TableName)
+		sb.WriteString(q.tableName(q.TableName))
 		sb.WriteString(" SET ")
-		i := 0
-		for field, value := range q.Updates {
-			sb.WriteString(field)
-			sb.WriteString(" = '")
-			sb.WriteString(value)
-			sb.WriteString("'")
-			if i < len(q.Updates)-1 {
+		for i, assignment := range q.Assignments {
+			sb.WriteString(name(assignment.Field))
+			sb.WriteString(" = ")
+			sb.WriteString(assignment.Value.String())
+			if i < len(q.Assignments)-1 {
 				sb.WriteString(", ")
 			}
-			i++
 		}
 	case Delete:
 		sb.WriteString("DELETE FROM ")
-		sb.WriteString(q.TableName)
+		sb.WriteString(q.tableName(q.TableName))
 	case Create:
 		sb.WriteString("CREATE TABLE ")
-		sb.WriteString(q.TableName)
+		if q.IfNotExists {
+			sb.WriteString("IF NOT EXISTS ")
+		}
+		sb.WriteString(q.tableName(q.TableName))
 		sb.WriteString(" (")
-		i := 0
-		for field, fieldType := range q.CreateFields {
-			sb.WriteString(field)
-			sb.WriteString(" ")
-			sb.WriteString(fieldType)
-			if i < len(q.CreateFields)-1 {
-				sb.WriteString(", ")
-			}
-			i++
+		definitions := []string{}
+		for _, column := range q.Columns {
+			definitions = append(definitions, q.columnString(column))
+		}
+		for _, constraint := range q.Constraints {
+			definitions = append(definitions, q.constraintString(constraint))
 		}
+		sb.WriteString(strings.Join(definitions, ", "))
 		sb.WriteString(")")
+	case Drop:
+		sb.WriteString("DROP TABLE ")
+		if q.IfExists {
+			sb.WriteString("IF EXISTS ")
+		}
+		sb.WriteString(q.tableName(q.TableName))
+	case Truncate:
+		sb.WriteString("TRUNCATE TABLE ")
+		sb.WriteString(q.tableName(q.TableName))
+	case Alter:
+		sb.WriteString("ALTER TABLE ")
+		sb.WriteString(q.tableName(q.TableName))
+		if q.Alter != nil {
+			sb.WriteString(" ")
+			sb.WriteString(q.alterString(*q.Alter))
+		}
 	default:
 		return ""
 	}
 
-	if len(q.Conditions) > 0 {
+	if where := q.where(); where != nil {
 		sb.WriteString(" WHERE ")
-		for i, cond := range q.Conditions {
-			if cond.Operand1IsField {
-				sb.WriteString(cond.Operand1)
-			} else {
-				sb.WriteString(fmt.Sprintf("'%s'", cond.Operand1))
-			}
-			sb.WriteString(" ")
-			sb.WriteString(cond.Operator.String())
-			sb.WriteString(" ")
+		sb.WriteString(where.String())
+	}
 
-			if cond.Operator == In || cond.Operator == NotIn {
-				sb.WriteString("('")
-				sb.WriteString(strings.Join(cond.InValues, "', '"))
-				sb.WriteString("')")
-			} else {
-				if cond.Operand2IsField {
-					sb.WriteString(cond.Operand2)
-				} else {
-					sb.WriteString(fmt.Sprintf("'%s'", cond.Operand2))
-				}
+	if len(q.GroupBy) > 0 {
+		sb.WriteString(" GROUP BY ")
+		for i, field := range q.GroupBy {
+			if i > 0 {
+				sb.WriteString(", ")
 			}
+			sb.WriteString(name(field))
+		}
+	}
+	if q.Having != nil {
+		sb.WriteString(" HAVING ")
+		sb.WriteString(q.Having.String())
+	}
 
-			if i < len(q.Conditions)-1 {
-				sb.WriteString(" AND ")
+	if len(q.OrderBy) > 0 {
+		sb.WriteString(" ORDER BY ")
+		for i, orderBy := range q.OrderBy {
+			sb.WriteString(name(orderBy.Field))
+			if orderBy.Desc {
+				sb.WriteString(" DESC")
+			}
+			if i < len(q.OrderBy)-1 {
+				sb.WriteString(", ")
 			}
 		}
 	}
+	if q.HasLimit {
+		sb.WriteString(fmt.Sprintf(" LIMIT %d", q.Limit))
+	}
+	if q.Offset > 0 {
+		sb.WriteString(fmt.Sprintf(" OFFSET %d", q.Offset))
+	}
 
 	return sb.String()
 }
 
+// joinValues returns values as a comma separated list of literals
+func joinValues(values []Value) string {
+	literals := make([]string, len(values))
+	for i, value := range values {
+		literals[i] = value.String()
+	}
+	return strings.Join(literals, ", ")
+}
+
+// columnString returns a column definition of CREATE TABLE with its constraints
+func (q Query) columnString(c Column) string {
+	var sb strings.Builder
+	sb.WriteString(quoteName(c.Name, q.QuotedNames[c.Name]))
+	sb.WriteString(" ")
+	sb.WriteString(c.TypeWithParams())
+	if c.PrimaryKey {
+		sb.WriteString(" PRIMARY KEY")
+	}
+	if c.NotNull {
+		sb.WriteString(" NOT NULL")
+	}
+	if c.Unique {
+		sb.WriteString(" UNIQUE")
+	}
+	if c.Default != nil {
+		sb.WriteString(" DEFAULT ")
+		sb.WriteString(c.Default.String())
+	}
+	if c.Check != nil {
+		sb.WriteString(" CHECK (")
+		sb.WriteString(c.Check.String())
+		sb.WriteString(")")
+	}
+	if c.References != nil {
+		sb.WriteString(" REFERENCES ")
+		sb.WriteString(q.referenceString(*c.References))
+	}
+	return sb.String()
+}
+
+// alterString returns the action of ALTER TABLE
+func (q Query) alterString(a AlterTable) string {
+	column := quoteName(a.Column.Name, q.QuotedNames[a.Column.Name])
+	switch a.Action {
+	case AddColumn:
+		return "ADD COLUMN " + q.columnString(a.Column)
+	case DropColumn:
+		return "DROP COLUMN " + column
+	case RenameColumn:
+		return "RENAME COLUMN " + column + " TO " + quoteName(a.NewName, q.QuotedNames[a.NewName])
+	case RenameTable:
+		return "RENAME TO " + q.tableName(a.NewName)
+	case AlterColumnType:
+		return "ALTER COLUMN " + column + " TYPE " + a.Column.TypeWithParams()
+	default:
+		return ""
+	}
+}
+
+// constraintString returns a table constraint of CREATE TABLE
+func (q Query) constraintString(c TableConstraint) string {
+	var sb strings.Builder
+	if c.Name != "" {
+		sb.WriteString("CONSTRAINT ")
+		sb.WriteString(quoteName(c.Name, q.QuotedNames[c.Name]))
+		sb.WriteString(" ")
+	}
+	switch c.Type {
+	case PrimaryKeyConstraint:
+		sb.WriteString("PRIMARY KEY ")
+		sb.WriteString(q.nameList(c.Columns))
+	case UniqueConstraint:
+		sb.WriteString("UNIQUE ")
+		sb.WriteString(q.nameList(c.Columns))
+	case CheckConstraint:
+		sb.WriteString("CHECK (")
+		sb.WriteString(c.Check.String())
+		sb.WriteString(")")
+	case ForeignKeyConstraint:
+		sb.WriteString("FOREIGN KEY ")
+		sb.WriteString(q.nameList(c.Columns))
+		if c.References != nil {
+			sb.WriteString(" REFERENCES ")
+			sb.WriteString(q.referenceString(*c.References))
+		}
+	}
+	return sb.String()
+}
+
+func (q Query) referenceString(r Reference) string {
+	return q.tableName(r.Table) + " " + q.nameList(r.Columns)
+}
+
+// nameList returns names as a parenthesized, comma separated list
+func (q Query) nameList(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quoteName(name, q.QuotedNames[name])
+	}
+	return "(" + strings.Join(quoted, ", ") + ")"
+}
+
+// tableName returns a table name as it can be parsed again: in double quotes when it was written as a
+// quoted identifier, and in single quotes, like 'logs/data.csv', when it isn't a bare identifier
+func (q Query) tableName(name string) string {
+	if q.QuotedNames[name] || isIdentifier(name) {
+		return quoteName(name, q.QuotedNames[name])
+	}
+	if strings.Contains(name, "'") {
+		return quoteName(name, true)
+	}
+	return "'" + name + "'"
+}
+
+// quoteName returns name in double quotes when it was written as a quoted identifier
+func quoteName(name string, quoted bool) string {
+	if !quoted {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// where returns the WHERE expression tree, building an AND chain from Conditions
+// when the Query was constructed by hand without a tree
+func (q Query) where() Expr {
+	if q.Where != nil {
+		return q.Where
+	}
+	var expr Expr
+	for _, cond := range q.Conditions {
+		if expr == nil {
+			expr = cond
+			continue
+		}
+		expr = &AndExpr{Left: expr, Right: cond}
+	}
+	return expr
+}
+
 // Type is the type of SQL query, e.g. SELECT/UPDATE
 type Type int
 
@@ -137,8 +474,14 @@ const (
 	Insert
 	// Delete represents a DELETE query
 	Delete
-	// Create
+	// Create represents a CREATE TABLE query
 	Create
+	// Drop represents a DROP TABLE query
+	Drop
+	// Alter represents an ALTER TABLE query
+	Alter
+	// Truncate represents a TRUNCATE query
+	Truncate
 )
 
 // TypeString is a string slice with the names of all types in order
@@ -149,6 +492,9 @@ var TypeString = []string{
 	"Insert",
 	"Delete",
 	"Create",
+	"Drop",
+	"Alter",
+	"Truncate",
 }
 
 // Operator is between operands in a condition
@@ -176,6 +522,14 @@ func (i Operator) String() string {
 		return "IN"
 	case NotIn:
 		return "NOT IN"
+	case Between:
+		return "BETWEEN"
+	case NotBetween:
+		return "NOT BETWEEN"
+	case IsNull:
+		return "IS NULL"
+	case IsNotNull:
+		return "IS NOT NULL"
 	default:
 		return "UnknownOperator"
 	}
@@ -204,6 +558,14 @@ const (
 	In
 	// NotIn -> "NOT IN"
 	NotIn
+	// Between -> "BETWEEN"
+	Between
+	// NotBetween -> "NOT BETWEEN"
+	NotBetween
+	// IsNull -> "IS NULL"
+	IsNull
+	// IsNotNull -> "IS NOT NULL"
+	IsNotNull
 )
 
 // OperatorString is a string slice with the names of all operators in order
@@ -219,6 +581,79 @@ var OperatorString = []string{
 	"NotLike",
 	"In",
 	"NotIn",
+	"Between",
+	"NotBetween",
+	"IsNull",
+	"IsNotNull",
+}
+
+// AggregateFunction is the function of an aggregate call, e.g. COUNT/AVG
+type AggregateFunction int
+
+func (f AggregateFunction) String() string {
+	switch f {
+	case Count:
+		return "COUNT"
+	case Sum:
+		return "SUM"
+	case Avg:
+		return "AVG"
+	case Min:
+		return "MIN"
+	case Max:
+		return "MAX"
+	default:
+		return "UnknownAggregate"
+	}
+}
+
+const (
+	// UnknownAggregate is the zero value for an AggregateFunction
+	UnknownAggregate AggregateFunction = iota
+	// Count -> "COUNT"
+	Count
+	// Sum -> "SUM"
+	Sum
+	// Avg -> "AVG"
+	Avg
+	// Min -> "MIN"
+	Min
+	// Max -> "MAX"
+	Max
+)
+
+// AggregateFunctionString is a string slice with the names of all aggregate functions in order
+var AggregateFunctionString = []string{
+	"UnknownAggregate",
+	"Count",
+	"Sum",
+	"Avg",
+	"Min",
+	"Max",
+}
+
+// aggregateFunction returns the AggregateFunction with the given case insensitive SQL name
+func aggregateFunction(name string) AggregateFunction {
+	for f := Count; f <= Max; f++ {
+		if strings.ToUpper(name) == f.String() {
+			return f
+		}
+	}
+	return UnknownAggregate
+}
+
+// Aggregate is an aggregate function call such as COUNT(*) or AVG(temp). Its String() form is the
+// name of the column that holds its result, in Query.Fields and in aggregated rows
+type Aggregate struct {
+	Function AggregateFunction
+	// Field is the aggregated field name, or "*" for COUNT(*)
+	Field string
+	// Quoted determines if Field was written as a quoted identifier
+	Quoted bool
+}
+
+func (a Aggregate) String() string {
+	return a.Function.String() + "(" + quoteName(a.Field, a.Quoted) + ")"
 }
 
 // Condition is a single boolean condition in a WHERE clause
@@ -227,12 +662,200 @@ type Condition struct {
 	Operand1 string
 	// Operand1IsField determines if Operand1 is a literal or a field name
 	Operand1IsField bool
+	// Operand1Quoted determines if the field name Operand1 was written as a quoted identifier
+	Operand1Quoted bool
+	// Operand1Value is the typed literal when Operand1IsField is false
+	Operand1Value Value
+	// Operand1Expr is the left hand side when it is neither a field nor a literal, e.g. "a + 1", and
+	// Operand1 is then its text
+	Operand1Expr ValueExpr
 	// Operator is e.g. "=", ">", "LIKE", "IN"
 	Operator Operator
-	// Operand2 is the right hand side operand (for LIKE, IN this can be a single value or list)
+	// Operand2 is the right hand side operand: a field name, or the text of a literal
 	Operand2 string
+	// Operand2Value is the typed literal when Operand2IsField is false
+	Operand2Value Value
 	// Operand2IsField determines if Operand2 is a literal or a field name
 	Operand2IsField bool
+	// Operand2Quoted determines if the field name Operand2 was written as a quoted identifier
+	Operand2Quoted bool
+	// Operand2Expr is the right hand side when it is neither a field nor a literal, e.g. "b * 2", and
+	// Operand2 is then its text
+	Operand2Expr ValueExpr
+	// Operand3 is the upper bound of BETWEEN, whose lower bound is Operand2: a field name, or the text of
+	// a literal
+	Operand3 string
+	// Operand3Value is the typed upper bound of BETWEEN when Operand3IsField is false
+	Operand3Value Value
+	// Operand3IsField determines if Operand3 is a literal or a field name
+	Operand3IsField bool
+	// Operand3Quoted determines if the field name Operand3 was written as a quoted identifier
+	Operand3Quoted bool
+	// Operand3Expr is the upper bound of BETWEEN when it is neither a field nor a literal, and Operand3 is
+	// then its text
+	Operand3Expr ValueExpr
 	// InValues holds the list of values for IN operator
-	InValues []string
+	InValues []Value
+}
+
+func (c Condition) String() string {
+	var sb strings.Builder
+	if c.Operand1Expr != nil {
+		sb.WriteString(c.Operand1Expr.String())
+	} else if c.Operand1IsField {
+		sb.WriteString(quoteName(c.Operand1, c.Operand1Quoted))
+	} else {
+		sb.WriteString(c.Operand1Value.String())
+	}
+	sb.WriteString(" ")
+	sb.WriteString(c.Operator.String())
+	if c.Operator == IsNull || c.Operator == IsNotNull {
+		return sb.String()
+	}
+	sb.WriteString(" ")
+
+	if c.Operator == In || c.Operator == NotIn {
+		sb.WriteString("(")
+		sb.WriteString(joinValues(c.InValues))
+		sb.WriteString(")")
+	} else {
+		sb.WriteString(operandString(c.Operand2, c.Operand2Value, c.Operand2IsField, c.Operand2Quoted, c.Operand2Expr))
+	}
+	if c.Operator == Between || c.Operator == NotBetween {
+		sb.WriteString(" AND ")
+		sb.WriteString(operandString(c.Operand3, c.Operand3Value, c.Operand3IsField, c.Operand3Quoted, c.Operand3Expr))
+	}
+	return sb.String()
+}
+
+// operandString returns the right hand side operand or the upper bound of BETWEEN of a condition
+func operandString(name string, value Value, isField, quoted bool, expr ValueExpr) string {
+	switch {
+	case expr != nil:
+		return expr.String()
+	case isField:
+		return quoteName(name, quoted)
+	default:
+		return value.String()
+	}
+}
+
+// Expr is a node of the boolean expression tree of a WHERE clause: a Condition,
+// an AndExpr, an OrExpr, a NotExpr or a ParenExpr
+type Expr interface {
+	String() string
+	expr()
+}
+
+// AndExpr is true when both Left and Right are true
+type AndExpr struct {
+	Left  Expr
+	Right Expr
+}
+
+// OrExpr is true when either Left or Right is true
+type OrExpr struct {
+	Left  Expr
+	Right Expr
+}
+
+// NotExpr negates Expr
+type NotExpr struct {
+	Expr Expr
+}
+
+// ParenExpr is an expression grouped in parentheses
+type ParenExpr struct {
+	Expr Expr
+}
+
+func (Condition) expr()  {}
+func (*AndExpr) expr()   {}
+func (*OrExpr) expr()    {}
+func (*NotExpr) expr()   {}
+func (*ParenExpr) expr() {}
+
+func (e *AndExpr) String() string {
+	return parenthesizeOr(e.Left) + " AND " + parenthesizeOr(e.Right)
+}
+
+func (e *OrExpr) String() string {
+	return e.Left.String() + " OR " + e.Right.String()
+}
+
+func (e *NotExpr) String() string {
+	switch e.Expr.(type) {
+	case *AndExpr, *OrExpr:
+		return "NOT (" + e.Expr.String() + ")"
+	}
+	return "NOT " + e.Expr.String()
+}
+
+func (e *ParenExpr) String() string {
+	return "(" + e.Expr.String() + ")"
+}
+
+// parenthesizeOr wraps an OrExpr operand of an AND in parentheses, so that trees built
+// without a ParenExpr keep their meaning when printed
+func parenthesizeOr(e Expr) string {
+	if _, ok := e.(*OrExpr); ok {
+		return "(" + e.String() + ")"
+	}
+	return e.String()
+}
+
+// fieldRefs returns the names of the fields a condition refers to, including in its expressions
+func (c Condition) fieldRefs() []string {
+	fields := []string{}
+	if c.Operand1IsField {
+		fields = append(fields, c.Operand1)
+	}
+	if c.Operand2IsField {
+		fields = append(fields, c.Operand2)
+	}
+	if c.Operand3IsField {
+		fields = append(fields, c.Operand3)
+	}
+	for _, expr := range []ValueExpr{c.Operand1Expr, c.Operand2Expr, c.Operand3Expr} {
+		if expr != nil {
+			fields = append(fields, fieldRefs(expr)...)
+		}
+	}
+	return fields
+}
+
+// conditionsOf returns every Condition in the tree, from left to right
+func conditionsOf(e Expr) []Condition {
+	switch e := e.(type) {
+	case Condition:
+		return []Condition{e}
+	case *AndExpr:
+		return append(conditionsOf(e.Left), conditionsOf(e.Right)...)
+	case *OrExpr:
+		return append(conditionsOf(e.Left), conditionsOf(e.Right)...)
+	case *NotExpr:
+		return conditionsOf(e.Expr)
+	case *ParenExpr:
+		return conditionsOf(e.Expr)
+	default:
+		return nil
+	}
+}
+
+// andConditions flattens a pure AND chain of conditions. It returns nil if the tree
+// contains any OR, NOT or parentheses
+func andConditions(e Expr) []Condition {
+	switch e := e.(type) {
+	case Condition:
+		return []Condition{e}
+	case *AndExpr:
+		left := andConditions(e.Left)
+		right := andConditions(e.Right)
+		if left == nil || right == nil {
+			return nil
+		}
+		return append(left, right...)
+	default:
+		return nil
+	}
 }
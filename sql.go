@@ -3,6 +3,7 @@ package sqlparser
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -31,7 +32,13 @@ func ParseMany(sqls []string) ([]Query, error) {
 }
 
 func parse(sql string) (Query, error) {
-	return (&parser{0, strings.TrimSpace(sql), stepType, Query{}, nil, ""}).parse()
+	p := &parser{sql: sql, tokens: Tokenize(sql), step: stepType, clause: "WHERE"}
+	q, err := p.parse()
+	if err != nil {
+		token := p.next()
+		return q, newParseError(sql, token.Offset, token.Text, err)
+	}
+	return q, nil
 }
 
 type step int
@@ -60,23 +67,31 @@ const (
 	stepDeleteFromTable
 	stepWhere
 	stepWhereField
-	stepWhereOperator
-	stepWhereValue
-	stepWhereAnd
+	stepWhereEnd
+	stepGroupBy
+	stepHaving
+	stepOrderBy
+	stepLimit
+	stepOffset
+	stepSelectEnd
 	stepCreateTable
 	stepParseCreateFields //()
-	stepWhereInOpeningParens
-	stepWhereInValue
-	stepWhereInCommaOrClosingParens
+	stepDropTable
+	stepTruncateTable
+	stepAlterTable
+	stepStatementEnd
 )
 
 type parser struct {
-	i               int
 	sql             string
+	tokens          []Token
+	i               int // index of the next token
 	step            step
 	query           Query
 	err             error
 	nextUpdateField string
+	clause          string // the clause whose conditions or expressions are being parsed, e.g. WHERE
+	caseDepth       int    // the number of CASE expressions being parsed
 }
 
 func (p *parser) parse() (Query, error) {
@@ -85,13 +100,12 @@ func (p *parser) parse() (Query, error) {
 	if p.err == nil {
 		p.err = p.validate()
 	}
-	p.logError()
 	return q, p.err
 }
 
 func (p *parser) doParse() (Query, error) {
 	for {
-		if p.i >= len(p.sql) {
+		if p.atEnd() {
 			return p.query, p.err
 		}
 		switch p.step {
@@ -108,7 +122,6 @@ func (p *parser) doParse() (Query, error) {
 				p.step = stepInsertTable
 			case "UPDATE":
 				p.query.Type = Update
-				p.query.Updates = map[string]string{}
 				p.pop()
 				p.step = stepUpdateTable
 			case "DELETE FROM":
@@ -119,64 +132,153 @@ func (p *parser) doParse() (Query, error) {
 				p.query.Type = Create
 				p.pop()
 				p.step = stepCreateTable
-				p.query.CreateFields = map[string]string{}
+			case "DROP TABLE":
+				p.query.Type = Drop
+				p.pop()
+				p.step = stepDropTable
+			case "ALTER TABLE":
+				p.query.Type = Alter
+				p.pop()
+				p.step = stepAlterTable
+			case "TRUNCATE":
+				p.query.Type = Truncate
+				p.pop()
+				if p.peek() == "TABLE" {
+					p.pop()
+				}
+				p.step = stepTruncateTable
 			default:
 				return p.query, fmt.Errorf("invalid query type")
 			}
+		case stepDropTable:
+			if p.peek() == "IF" {
+				p.pop()
+				if p.peek() != "EXISTS" {
+					return p.query, expectedErrorf([]string{"EXISTS"}, "at DROP TABLE: expected EXISTS")
+				}
+				p.pop()
+				p.query.IfExists = true
+			}
+			if err := p.popTableName("DROP TABLE"); err != nil {
+				return p.query, err
+			}
+			p.step = stepStatementEnd
+		case stepTruncateTable:
+			if err := p.popTableName("TRUNCATE"); err != nil {
+				return p.query, err
+			}
+			p.step = stepStatementEnd
+		case stepAlterTable:
+			if err := p.popTableName("ALTER TABLE"); err != nil {
+				return p.query, err
+			}
+			alter, err := p.parseAlterAction()
+			if err != nil {
+				return p.query, err
+			}
+			p.query.Alter = &alter
+			p.step = stepStatementEnd
+		case stepStatementEnd:
+			return p.query, fmt.Errorf("unexpected '%s'", p.peek())
 		case stepCreateTable:
-			tableName := p.peek()
+			if p.peek() == "IF" {
+				p.pop()
+				if p.peek() != "NOT" {
+					return p.query, expectedErrorf([]string{"NOT"}, "syntax error, expect NOT EXISTS")
+				}
+				p.pop()
+				if p.peek() != "EXISTS" {
+					return p.query, expectedErrorf([]string{"EXISTS"}, "syntax error, expect EXISTS")
+				}
+				p.pop()
+				p.query.IfNotExists = true
+			}
+			tableName := p.peekName()
 			if tableName == "" {
 				return p.query, fmt.Errorf("missing table name")
 			}
 			p.query.TableName = tableName
-			p.pop()
+			p.popName()
 			leftBracket := p.peek() // (
 			if leftBracket != "(" {
-				return p.query, fmt.Errorf("syntax error, expect '(")
+				return p.query, expectedErrorf([]string{"("}, "syntax error, expect '(")
 			}
 			p.step = stepParseCreateFields
 			p.pop()
 		case stepParseCreateFields:
-			field := p.peek()
-			if field == "" {
-				return p.query, fmt.Errorf("syntax error, expect filed name")
+			if tableConstraintKeywords[p.peek()] {
+				constraint, err := p.parseTableConstraint()
+				if err != nil {
+					return p.query, err
+				}
+				p.query.Constraints = append(p.query.Constraints, constraint)
+				if err := p.popCreateFieldsSeparator(); err != nil {
+					return p.query, err
+				}
+				continue
 			}
-			p.pop()
-			Type := p.peek()
-			if Type == "" {
-				return p.query, fmt.Errorf("syntax error, expect filed type")
+			if len(p.query.Constraints) > 0 {
+				return p.query, expectedErrorf([]string{"constraint"}, "syntax error, expect table constraint after the first one")
 			}
-			p.pop()
-			p.query.CreateFields[field] = Type
-			NToken := p.peek()
-			switch NToken {
-			case ",":
-				p.pop()
-				p.step = stepParseCreateFields
-			case ")":
-				p.pop()
-			default:
-				return p.query, fmt.Errorf("syntax error, expect ')'")
+			field := p.peekName()
+			if !p.isName(field) {
+				return p.query, expectedErrorf([]string{"column"}, "at CREATE TABLE: expected column name")
+			}
+			for _, column := range p.query.Columns {
+				if column.Name == field {
+					return p.query, fmt.Errorf("at CREATE TABLE: duplicate column %s", field)
+				}
+			}
+			p.popName()
+			column := Column{Name: field}
+			if err := p.parseColumnType(&column); err != nil {
+				return p.query, err
+			}
+			if err := p.parseColumnConstraints(&column); err != nil {
+				return p.query, err
+			}
+			p.query.Columns = append(p.query.Columns, column)
+			if err := p.popCreateFieldsSeparator(); err != nil {
+				return p.query, err
 			}
 		case stepSelectField:
-			identifier := p.peek()
-			if !isIdentifierOrAsterisk(identifier) {
-				return p.query, fmt.Errorf("at SELECT: expected field to SELECT")
+			identifier := "*"
+			if p.peek() == "*" {
+				p.pop()
+			} else {
+				if !p.startsValueExpr() {
+					return p.query, expectedErrorf([]string{"field"}, "at SELECT: expected field to SELECT")
+				}
+				expr, err := p.parseValueExprIn("SELECT")
+				if err != nil {
+					return p.query, err
+				}
+				if field, ok := expr.(*FieldRef); ok {
+					identifier = field.Name
+					if field.Quoted {
+						p.markQuoted(identifier)
+					}
+				} else {
+					identifier = expr.String()
+					if p.query.FieldExprs == nil {
+						p.query.FieldExprs = map[string]ValueExpr{}
+					}
+					p.query.FieldExprs[identifier] = expr
+				}
 			}
 			p.query.Fields = append(p.query.Fields, identifier)
-			p.pop()
 			maybeFrom := p.peek()
 			if strings.ToUpper(maybeFrom) == "AS" {
 				p.pop()
-				alias := p.peek()
-				if !isIdentifier(alias) {
+				alias := p.peekName()
+				if !p.isName(alias) {
 					return p.query, fmt.Errorf("at SELECT: expected field alias for \"" + identifier + " as\" to SELECT")
 				}
 				if p.query.Aliases == nil {
 					p.query.Aliases = make(map[string]string)
 				}
 				p.query.Aliases[identifier] = alias
-				p.pop()
+				p.popName()
 				maybeFrom = p.peek()
 			}
 			if strings.ToUpper(maybeFrom) == "FROM" {
@@ -187,79 +289,83 @@ func (p *parser) doParse() (Query, error) {
 		case stepSelectComma:
 			commaRWord := p.peek()
 			if commaRWord != "," {
-				return p.query, fmt.Errorf("at SELECT: expected comma or FROM")
+				return p.query, expectedErrorf([]string{",", "FROM"}, "at SELECT: expected comma or FROM")
 			}
 			p.pop()
 			p.step = stepSelectField
 		case stepSelectFrom:
 			fromRWord := p.peek()
 			if strings.ToUpper(fromRWord) != "FROM" {
-				return p.query, fmt.Errorf("at SELECT: expected FROM")
+				return p.query, expectedErrorf([]string{"FROM"}, "at SELECT: expected FROM")
 			}
 			p.pop()
 			p.step = stepSelectFromTable
 		case stepSelectFromTable:
-			tableName := p.peek()
+			tableName := p.peekName()
 			if len(tableName) == 0 {
-				return p.query, fmt.Errorf("at SELECT: expected quoted table name")
+				return p.query, expectedErrorf([]string{"quoted table name"}, "at SELECT: expected quoted table name")
 			}
 			p.query.TableName = tableName
-			p.pop()
+			p.popName()
 			p.step = stepWhere
 		case stepInsertTable:
-			tableName := p.peek()
+			tableName := p.peekName()
 			if len(tableName) == 0 {
-				return p.query, fmt.Errorf("at INSERT INTO: expected quoted table name")
+				return p.query, expectedErrorf([]string{"quoted table name"}, "at INSERT INTO: expected quoted table name")
 			}
 			p.query.TableName = tableName
-			p.pop()
+			p.popName()
 			p.step = stepInsertFieldsOpeningParens
 		case stepDeleteFromTable:
-			tableName := p.peek()
+			tableName := p.peekName()
 			if len(tableName) == 0 {
-				return p.query, fmt.Errorf("at DELETE FROM: expected quoted table name")
+				return p.query, expectedErrorf([]string{"quoted table name"}, "at DELETE FROM: expected quoted table name")
 			}
 			p.query.TableName = tableName
-			p.pop()
+			p.popName()
 			p.step = stepWhere
 		case stepUpdateTable:
-			tableName := p.peek()
+			tableName := p.peekName()
 			if len(tableName) == 0 {
-				return p.query, fmt.Errorf("at UPDATE: expected quoted table name")
+				return p.query, expectedErrorf([]string{"quoted table name"}, "at UPDATE: expected quoted table name")
 			}
 			p.query.TableName = tableName
-			p.pop()
+			p.popName()
 			p.step = stepUpdateSet
 		case stepUpdateSet:
 			setRWord := p.peek()
 			if setRWord != "SET" {
-				return p.query, fmt.Errorf("at UPDATE: expected 'SET'")
+				return p.query, expectedErrorf([]string{"SET"}, "at UPDATE: expected 'SET'")
 			}
 			p.pop()
 			p.step = stepUpdateField
 		case stepUpdateField:
-			identifier := p.peek()
-			if !isIdentifier(identifier) {
-				return p.query, fmt.Errorf("at UPDATE: expected at least one field to update")
+			identifier := p.peekName()
+			if !p.isName(identifier) {
+				return p.query, expectedErrorf([]string{"field"}, "at UPDATE: expected at least one field to update")
+			}
+			for _, assignment := range p.query.Assignments {
+				if assignment.Field == identifier {
+					return p.query, fmt.Errorf("at UPDATE: duplicate column %s", identifier)
+				}
 			}
 			p.nextUpdateField = identifier
-			p.pop()
+			p.popName()
 			p.step = stepUpdateEquals
 		case stepUpdateEquals:
 			equalsRWord := p.peek()
 			if equalsRWord != "=" {
-				return p.query, fmt.Errorf("at UPDATE: expected '='")
+				return p.query, expectedErrorf([]string{"="}, "at UPDATE: expected '='")
 			}
 			p.pop()
 			p.step = stepUpdateValue
 		case stepUpdateValue:
-			quotedValue, ln := p.peekQuotedStringWithLength()
-			if ln == 0 {
-				return p.query, fmt.Errorf("at UPDATE: expected quoted value")
+			value, err := p.parseValueExprIn("UPDATE")
+			if err != nil {
+				return p.query, err
 			}
-			p.query.Updates[p.nextUpdateField] = quotedValue
+			p.query.Assignments = append(p.query.Assignments, Assignment{Field: p.nextUpdateField, Value: value})
 			p.nextUpdateField = ""
-			p.pop()
 			maybeWhere := p.peek()
 			if strings.ToUpper(maybeWhere) == "WHERE" {
 				p.step = stepWhere
@@ -269,145 +375,166 @@ func (p *parser) doParse() (Query, error) {
 		case stepUpdateComma:
 			commaRWord := p.peek()
 			if commaRWord != "," {
-				return p.query, fmt.Errorf("at UPDATE: expected ','")
+				return p.query, expectedErrorf([]string{","}, "at UPDATE: expected ','")
 			}
 			p.pop()
 			p.step = stepUpdateField
 		case stepWhere:
 			whereRWord := p.peek()
 			if strings.ToUpper(whereRWord) != "WHERE" {
-				return p.query, fmt.Errorf("expected WHERE")
+				if p.query.Type == Select {
+					p.step = stepGroupBy
+					continue
+				}
+				return p.query, expectedErrorf([]string{"WHERE"}, "expected WHERE")
 			}
 			p.pop()
 			p.step = stepWhereField
 		case stepWhereField:
-			identifier := p.peek()
-			if !isIdentifier(identifier) {
-				return p.query, fmt.Errorf("at WHERE: expected field")
+			expr, err := p.parseOrExpr()
+			if err != nil {
+				return p.query, err
+			}
+			p.query.Where = expr
+			p.query.Conditions = andConditions(expr)
+			p.step = stepWhereEnd
+		case stepWhereEnd:
+			if p.query.Type == Select {
+				p.step = stepGroupBy
+				continue
+			}
+			return p.query, expectedErrorf([]string{"AND", "OR"}, "at WHERE: expected AND or OR")
+		case stepGroupBy:
+			groupRWord := p.peek()
+			if strings.ToUpper(groupRWord) != "GROUP" {
+				p.step = stepHaving
+				continue
 			}
-			p.query.Conditions = append(p.query.Conditions, Condition{Operand1: identifier, Operand1IsField: true})
 			p.pop()
-			p.step = stepWhereOperator
-		case stepWhereOperator:
-			operator := p.peek()
-			currentCondition := p.query.Conditions[len(p.query.Conditions)-1]
-			switch operator {
-			case "=":
-				currentCondition.Operator = Eq
-			case ">":
-				currentCondition.Operator = Gt
-			case ">=":
-				currentCondition.Operator = Gte
-			case "<":
-				currentCondition.Operator = Lt
-			case "<=":
-				currentCondition.Operator = Lte
-			case "!=":
-				currentCondition.Operator = Ne
-			case "LIKE":
-				currentCondition.Operator = Like
-			case "NOT LIKE":
-				currentCondition.Operator = NotLike
-			case "IN":
-				currentCondition.Operator = In
-			case "NOT IN":
-				currentCondition.Operator = NotIn
-			default:
-				return p.query, fmt.Errorf("at WHERE: unknown operator")
+			byRWord := p.peek()
+			if strings.ToUpper(byRWord) != "BY" {
+				return p.query, expectedErrorf([]string{"BY"}, "at GROUP BY: expected BY")
 			}
-			p.query.Conditions[len(p.query.Conditions)-1] = currentCondition
 			p.pop()
-
-			// For IN and NOT IN operators, expect opening parenthesis
-			if currentCondition.Operator == In || currentCondition.Operator == NotIn {
-				p.step = stepWhereInOpeningParens
-			} else {
-				p.step = stepWhereValue
+			for {
+				identifier := p.peekName()
+				if p.isQuoted() || !p.isName(identifier) {
+					return p.query, expectedErrorf([]string{"field"}, "at GROUP BY: expected field")
+				}
+				p.query.GroupBy = append(p.query.GroupBy, identifier)
+				p.popName()
+				if p.peek() != "," {
+					break
+				}
+				p.pop()
 			}
-		case stepWhereInOpeningParens:
-			openingParens := p.peek()
-			if openingParens != "(" {
-				return p.query, fmt.Errorf("at WHERE IN: expected opening parenthesis")
+			p.step = stepHaving
+		case stepHaving:
+			havingRWord := p.peek()
+			if strings.ToUpper(havingRWord) != "HAVING" {
+				p.step = stepOrderBy
+				continue
 			}
 			p.pop()
-			p.step = stepWhereInValue
-		case stepWhereInValue:
-			quotedValue, ln := p.peekQuotedStringWithLength()
-			if ln == 0 {
-				return p.query, fmt.Errorf("at WHERE IN: expected quoted value")
-			}
-			currentCondition := &p.query.Conditions[len(p.query.Conditions)-1]
-			currentCondition.InValues = append(currentCondition.InValues, quotedValue)
+			if p.atEnd() {
+				return p.query, expectedErrorf([]string{"field"}, "at HAVING: empty HAVING clause")
+			}
+			p.clause = "HAVING"
+			expr, err := p.parseOrExpr()
+			if err != nil {
+				return p.query, err
+			}
+			p.clause = "WHERE"
+			p.query.Having = expr
+			p.step = stepOrderBy
+		case stepOrderBy:
+			orderRWord := p.peek()
+			if strings.ToUpper(orderRWord) != "ORDER" {
+				p.step = stepLimit
+				continue
+			}
 			p.pop()
-			p.step = stepWhereInCommaOrClosingParens
-		case stepWhereInCommaOrClosingParens:
-			commaOrClosingParens := p.peek()
-			if commaOrClosingParens == "" {
-				return p.query, fmt.Errorf("at WHERE IN: expected closing parenthesis")
+			byRWord := p.peek()
+			if strings.ToUpper(byRWord) != "BY" {
+				return p.query, expectedErrorf([]string{"BY"}, "at ORDER BY: expected BY")
 			}
 			p.pop()
-			if commaOrClosingParens == "," {
-				p.step = stepWhereInValue
-				continue
-			} else if commaOrClosingParens == ")" {
-				p.step = stepWhereAnd
-				continue
-			} else {
-				return p.query, fmt.Errorf("at WHERE IN: expected comma or closing parenthesis")
-			}
-		case stepWhereValue:
-			currentCondition := &p.query.Conditions[len(p.query.Conditions)-1]
-			// For LIKE and NOT LIKE, the operand must be a quoted string.
-			if currentCondition.Operator == Like || currentCondition.Operator == NotLike {
-				quotedValue, ln := p.peekQuotedStringWithLength()
-				if ln == 0 {
-					return p.query, fmt.Errorf("at WHERE: expected quoted value for LIKE/NOT LIKE")
+			for {
+				identifier := p.peekName()
+				if p.isQuoted() || !p.isName(identifier) {
+					return p.query, expectedErrorf([]string{"field"}, "at ORDER BY: expected field")
 				}
-				currentCondition.Operand2 = quotedValue
-				currentCondition.Operand2IsField = false
-			} else {
-				// For other operators, it can be an identifier or a quoted string.
-				identifier := p.peek()
-				if isIdentifier(identifier) {
-					currentCondition.Operand2 = identifier
-					currentCondition.Operand2IsField = true
-				} else {
-					quotedValue, ln := p.peekQuotedStringWithLength()
-					if ln == 0 {
-						return p.query, fmt.Errorf("at WHERE: expected quoted value")
+				p.popName()
+				if p.peek() == "(" {
+					aggregate, err := p.parseAggregate(identifier)
+					if err != nil {
+						return p.query, fmt.Errorf("at ORDER BY: %w", err)
 					}
-					currentCondition.Operand2 = quotedValue
-					currentCondition.Operand2IsField = false
+					identifier = aggregate.String()
 				}
+				orderBy := OrderBy{Field: identifier}
+				switch p.peek() {
+				case "ASC":
+					p.pop()
+				case "DESC":
+					orderBy.Desc = true
+					p.pop()
+				}
+				p.query.OrderBy = append(p.query.OrderBy, orderBy)
+				if p.peek() != "," {
+					break
+				}
+				p.pop()
+			}
+			p.step = stepLimit
+		case stepLimit:
+			limitRWord := p.peek()
+			if strings.ToUpper(limitRWord) != "LIMIT" {
+				p.step = stepOffset
+				continue
 			}
 			p.pop()
-			p.step = stepWhereAnd
-		case stepWhereAnd:
-			andRWord := p.peek()
-			if strings.ToUpper(andRWord) != "AND" {
-				return p.query, fmt.Errorf("expected AND")
+			limit, err := p.popCount()
+			if err != nil {
+				return p.query, fmt.Errorf("at LIMIT: %w", err)
+			}
+			p.query.Limit = limit
+			p.query.HasLimit = true
+			p.step = stepOffset
+		case stepOffset:
+			offsetRWord := p.peek()
+			if strings.ToUpper(offsetRWord) != "OFFSET" {
+				p.step = stepSelectEnd
+				continue
 			}
 			p.pop()
-			p.step = stepWhereField
+			offset, err := p.popCount()
+			if err != nil {
+				return p.query, fmt.Errorf("at OFFSET: %w", err)
+			}
+			p.query.Offset = offset
+			p.step = stepSelectEnd
+		case stepSelectEnd:
+			return p.query, fmt.Errorf("at SELECT: unexpected '%s'", p.peek())
 		case stepInsertFieldsOpeningParens:
 			openingParens := p.peek()
 			if len(openingParens) != 1 || openingParens != "(" {
-				return p.query, fmt.Errorf("at INSERT INTO: expected opening parens")
+				return p.query, expectedErrorf([]string{"("}, "at INSERT INTO: expected opening parens")
 			}
 			p.pop()
 			p.step = stepInsertFields
 		case stepInsertFields:
-			identifier := p.peek()
-			if !isIdentifier(identifier) {
-				return p.query, fmt.Errorf("at INSERT INTO: expected at least one field to insert")
+			identifier := p.peekName()
+			if !p.isName(identifier) {
+				return p.query, expectedErrorf([]string{"field"}, "at INSERT INTO: expected at least one field to insert")
 			}
 			p.query.Fields = append(p.query.Fields, identifier)
-			p.pop()
+			p.popName()
 			p.step = stepInsertFieldsCommaOrClosingParens
 		case stepInsertFieldsCommaOrClosingParens:
 			commaOrClosingParens := p.peek()
 			if commaOrClosingParens != "," && commaOrClosingParens != ")" {
-				return p.query, fmt.Errorf("at INSERT INTO: expected comma or closing parens")
+				return p.query, expectedErrorf([]string{",", ")"}, "at INSERT INTO: expected comma or closing parens")
 			}
 			p.pop()
 			if commaOrClosingParens == "," {
@@ -418,30 +545,35 @@ func (p *parser) doParse() (Query, error) {
 		case stepInsertValuesRWord:
 			valuesRWord := p.peek()
 			if strings.ToUpper(valuesRWord) != "VALUES" {
-				return p.query, fmt.Errorf("at INSERT INTO: expected 'VALUES'")
+				return p.query, expectedErrorf([]string{"VALUES"}, "at INSERT INTO: expected 'VALUES'")
 			}
 			p.pop()
 			p.step = stepInsertValuesOpeningParens
 		case stepInsertValuesOpeningParens:
 			openingParens := p.peek()
 			if openingParens != "(" {
-				return p.query, fmt.Errorf("at INSERT INTO: expected opening parens")
+				return p.query, expectedErrorf([]string{"("}, "at INSERT INTO: expected opening parens")
 			}
-			p.query.Inserts = append(p.query.Inserts, []string{})
+			p.query.Inserts = append(p.query.Inserts, []ValueExpr{})
 			p.pop()
 			p.step = stepInsertValues
 		case stepInsertValues:
-			quotedValue, ln := p.peekQuotedStringWithLength()
-			if ln == 0 {
-				return p.query, fmt.Errorf("at INSERT INTO: expected quoted value")
+			if !p.startsValueExpr() {
+				return p.query, expectedErrorf([]string{"value"}, "at INSERT INTO: expected quoted value")
 			}
-			p.query.Inserts[len(p.query.Inserts)-1] = append(p.query.Inserts[len(p.query.Inserts)-1], quotedValue)
-			p.pop()
+			expr, err := p.parseValueExprIn("INSERT INTO")
+			if err != nil {
+				return p.query, err
+			}
+			if fields := fieldRefs(expr); len(fields) > 0 {
+				return p.query, fmt.Errorf("at INSERT INTO: values cannot refer to field %s", fields[0])
+			}
+			p.query.Inserts[len(p.query.Inserts)-1] = append(p.query.Inserts[len(p.query.Inserts)-1], expr)
 			p.step = stepInsertValuesCommaOrClosingParens
 		case stepInsertValuesCommaOrClosingParens:
 			commaOrClosingParens := p.peek()
 			if commaOrClosingParens != "," && commaOrClosingParens != ")" {
-				return p.query, fmt.Errorf("at INSERT INTO: expected comma or closing parens")
+				return p.query, expectedErrorf([]string{",", ")"}, "at INSERT INTO: expected comma or closing parens")
 			}
 			p.pop()
 			if commaOrClosingParens == "," {
@@ -456,7 +588,7 @@ func (p *parser) doParse() (Query, error) {
 		case stepInsertValuesCommaBeforeOpeningParens:
 			commaRWord := p.peek()
 			if strings.ToUpper(commaRWord) != "," {
-				return p.query, fmt.Errorf("at INSERT INTO: expected comma")
+				return p.query, expectedErrorf([]string{","}, "at INSERT INTO: expected comma")
 			}
 			p.pop()
 			p.step = stepInsertValuesOpeningParens
@@ -464,87 +596,966 @@ func (p *parser) doParse() (Query, error) {
 	}
 }
 
-func (p *parser) peek() string {
-	peeked, _ := p.peekWithLength()
-	return peeked
+// parseOrExpr parses AND expressions joined by OR, which binds the loosest
+func (p *parser) parseOrExpr() (Expr, error) {
+	left, err := p.parseAndExpr()
+	if err != nil {
+		return nil, err
+	}
+	for strings.ToUpper(p.peek()) == "OR" {
+		p.pop()
+		right, err := p.parseAndExpr()
+		if err != nil {
+			return nil, err
+		}
+		left = &OrExpr{Left: left, Right: right}
+	}
+	return left, nil
 }
 
-func (p *parser) pop() string {
-	peeked, len := p.peekWithLength()
-	p.i += len
-	p.popWhitespace()
-	return peeked
+// parseAndExpr parses NOT expressions joined by AND
+func (p *parser) parseAndExpr() (Expr, error) {
+	left, err := p.parseNotExpr()
+	if err != nil {
+		return nil, err
+	}
+	for strings.ToUpper(p.peek()) == "AND" {
+		p.pop()
+		right, err := p.parseNotExpr()
+		if err != nil {
+			return nil, err
+		}
+		left = &AndExpr{Left: left, Right: right}
+	}
+	return left, nil
 }
 
-func (p *parser) popWhitespace() {
-	for ; p.i < len(p.sql) && p.sql[p.i] == ' '; p.i++ {
+// parseNotExpr parses an optionally negated condition or parenthesized expression
+func (p *parser) parseNotExpr() (Expr, error) {
+	if p.peekKeyword("NOT") {
+		p.pop()
+		expr, err := p.parseNotExpr()
+		if err != nil {
+			return nil, err
+		}
+		return &NotExpr{Expr: expr}, nil
+	}
+	if p.peekPunctuation("(") && !p.parenthesizedOperand() {
+		p.pop()
+		expr, err := p.parseOrExpr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, expectedErrorf([]string{")"}, "at %s: expected closing parenthesis", p.clause)
+		}
+		p.pop()
+		return &ParenExpr{Expr: expr}, nil
 	}
+	return p.parseCondition()
 }
 
-var reservedWords = []string{
-	"(", ")", ">=", "<=", "!=", ",", "=", ">", "<", "SELECT", "INSERT INTO", "VALUES", "UPDATE", "DELETE FROM",
-	"WHERE", "FROM", "SET", "AS", "CREATE TABLE", "LIKE", "NOT LIKE", "IN", "NOT IN",
+// parenthesizedOperand reports whether the parenthesis at the next token starts the operand of a
+// condition, as in "(a + 1) * 2 > b", rather than a group of conditions: the token after the matching
+// parenthesis is then an operator
+func (p *parser) parenthesizedOperand() bool {
+	depth := 0
+	for i := p.i; i < len(p.tokens); i++ {
+		if p.tokens[i].Kind != PunctuationToken {
+			continue
+		}
+		switch p.tokens[i].Value {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth > 0 {
+				continue
+			}
+			if i+1 == len(p.tokens) {
+				return false
+			}
+			next := p.tokens[i+1]
+			if next.Kind == OperatorToken {
+				return true
+			}
+			for _, operator := range conditionOperators {
+				if next.Kind == KeywordToken && next.Value == operator {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return false
 }
 
-func (p *parser) peekWithLength() (string, int) {
-	if p.i >= len(p.sql) {
-		return "", 0
+// parseCondition parses a single comparison such as "a = '1'", "'1' < a" or "a IN ('1', '2')"
+func (p *parser) parseCondition() (Expr, error) {
+	var cond Condition
+	if !p.startsValueExpr() {
+		return nil, expectedErrorf([]string{"field", "value"}, "at %s: expected field", p.clause)
 	}
-	for _, rWord := range reservedWords {
-		token := strings.ToUpper(p.sql[p.i:min(len(p.sql), p.i+len(rWord))])
-		if token == rWord {
-			return token, len(token)
+	start := p.i
+	left, err := p.parseValueExpr()
+	if err != nil {
+		return nil, err
+	}
+	cond.Operand1, cond.Operand1Value, cond.Operand1IsField, cond.Operand1Quoted, cond.Operand1Expr = p.operand(left, start)
+
+	operator := p.peek()
+	switch operator {
+	case "=":
+		cond.Operator = Eq
+	case ">":
+		cond.Operator = Gt
+	case ">=":
+		cond.Operator = Gte
+	case "<":
+		cond.Operator = Lt
+	case "<=":
+		cond.Operator = Lte
+	case "!=":
+		cond.Operator = Ne
+	case "LIKE":
+		cond.Operator = Like
+	case "NOT LIKE":
+		cond.Operator = NotLike
+	case "IN":
+		cond.Operator = In
+	case "NOT IN":
+		cond.Operator = NotIn
+	case "BETWEEN":
+		cond.Operator = Between
+	case "NOT BETWEEN":
+		cond.Operator = NotBetween
+	case "IS":
+		p.pop()
+		cond.Operator = IsNull
+		if p.peek() == "NOT" {
+			p.pop()
+			cond.Operator = IsNotNull
+		}
+		if p.peek() != "NULL" {
+			return nil, expectedErrorf([]string{"NULL"}, "at %s: expected NULL after IS", p.clause)
 		}
+		p.pop()
+		return cond, nil
+	case "":
+		return nil, expectedErrorf(conditionOperators, "at %s: condition without operator", p.clause)
+	default:
+		return nil, expectedErrorf(conditionOperators, "at %s: unknown operator", p.clause)
 	}
-	if p.sql[p.i] == '\'' { // Quoted string
-		return p.peekQuotedStringWithLength()
+	p.pop()
+
+	switch cond.Operator {
+	case In, NotIn:
+		if !p.peekPunctuation("(") {
+			return nil, expectedErrorf([]string{"("}, "at %s IN: expected opening parenthesis", p.clause)
+		}
+		p.pop()
+		for {
+			value, ok := p.peekValue()
+			if !ok {
+				return nil, expectedErrorf([]string{"value"}, "at %s IN: expected quoted value", p.clause)
+			}
+			cond.InValues = append(cond.InValues, value)
+			p.pop()
+			if p.peekPunctuation(")") {
+				p.pop()
+				break
+			}
+			if p.atEnd() {
+				return nil, expectedErrorf([]string{")"}, "at %s IN: expected closing parenthesis", p.clause)
+			}
+			if !p.peekPunctuation(",") {
+				return nil, expectedErrorf([]string{",", ")"}, "at %s IN: expected comma or closing parenthesis", p.clause)
+			}
+			p.pop()
+		}
+	case Between, NotBetween:
+		// The AND between the bounds belongs to BETWEEN and not to the WHERE clause
+		if !p.startsValueExpr() {
+			return nil, expectedErrorf([]string{"value", "field"}, "at %s BETWEEN: expected value", p.clause)
+		}
+		start := p.i
+		low, err := p.parseValueExpr()
+		if err != nil {
+			return nil, err
+		}
+		cond.Operand2, cond.Operand2Value, cond.Operand2IsField, cond.Operand2Quoted, cond.Operand2Expr = p.operand(low, start)
+		if !p.peekKeyword("AND") {
+			return nil, expectedErrorf([]string{"AND"}, "at %s BETWEEN: expected AND", p.clause)
+		}
+		p.pop()
+		if !p.startsValueExpr() {
+			return nil, expectedErrorf([]string{"value", "field"}, "at %s BETWEEN: expected value", p.clause)
+		}
+		start = p.i
+		high, err := p.parseValueExpr()
+		if err != nil {
+			return nil, err
+		}
+		cond.Operand3, cond.Operand3Value, cond.Operand3IsField, cond.Operand3Quoted, cond.Operand3Expr = p.operand(high, start)
+	case Like, NotLike:
+		// For LIKE and NOT LIKE, the operand must be a quoted string.
+		quotedValue, ok := p.peekQuotedString()
+		if !ok {
+			return nil, expectedErrorf([]string{"quoted value"}, "at %s: expected quoted value for LIKE/NOT LIKE", p.clause)
+		}
+		cond.Operand2 = quotedValue
+		cond.Operand2Value = NewString(quotedValue)
+		p.pop()
+	default:
+		// For other operators, it can be an identifier, a literal or an expression.
+		if !p.startsValueExpr() {
+			return nil, expectedErrorf([]string{"value", "field"}, "at %s: expected quoted value", p.clause)
+		}
+		start := p.i
+		right, err := p.parseValueExpr()
+		if err != nil {
+			return nil, err
+		}
+		cond.Operand2, cond.Operand2Value, cond.Operand2IsField, cond.Operand2Quoted, cond.Operand2Expr = p.operand(right, start)
 	}
-	return p.peekIdentifierWithLength()
+	return cond, nil
 }
 
-func (p *parser) peekQuotedStringWithLength() (string, int) {
-	if len(p.sql) < p.i || p.sql[p.i] != '\'' {
-		return "", 0
+// operand returns the parts of a condition operand parsed from the token at start: the name of a
+// field, or the text of a literal and its value, or the text of any other expression and the expression
+func (p *parser) operand(expr ValueExpr, start int) (text string, value Value, isField, quoted bool, complex ValueExpr) {
+	switch e := expr.(type) {
+	case *FieldRef:
+		return e.Name, Value{}, true, e.Quoted, nil
+	case *Literal:
+		if p.i == start+1 {
+			return p.tokens[start].Value, e.Value, false, false, nil
+		}
 	}
-	for i := p.i + 1; i < len(p.sql); i++ {
-		if p.sql[i] == '\'' && p.sql[i-1] != '\\' {
-			return p.sql[p.i+1 : i], len(p.sql[p.i+1:i]) + 2 // +2 for the two quotes
+	return expr.String(), Value{}, false, false, expr
+}
+
+// parseColumnType parses the type of a column and its parenthesized parameters, e.g. VARCHAR(255)
+func (p *parser) parseColumnType(column *Column) error {
+	if token := p.next(); token.Kind != IdentifierToken || token.Quoted {
+		return expectedErrorf([]string{"type"}, "syntax error, expect filed type")
+	}
+	column.Type = p.peek()
+	p.pop()
+	if p.peek() != "(" {
+		return nil
+	}
+	p.pop()
+	for {
+		param := p.next()
+		if param.Kind != NumberToken && param.Kind != StringToken {
+			return expectedErrorf([]string{"type parameter"}, "syntax error, expect type parameter")
 		}
+		column.TypeParams = append(column.TypeParams, param.Text)
+		p.pop()
+		if p.peek() != "," {
+			break
+		}
+		p.pop()
+	}
+	if p.peek() != ")" {
+		return expectedErrorf([]string{",", ")"}, "syntax error, expect ')'")
 	}
-	return "", 0
+	p.pop()
+	return nil
 }
 
-func (p *parser) peekIdentifierWithLength() (string, int) {
-	start := p.i
-	for i := start; i < len(p.sql); i++ {
-		ch := p.sql[i]
-		if !(ch >= 'a' && ch <= 'z' ||
-			ch >= 'A' && ch <= 'Z' ||
-			ch >= '0' && ch <= '9' ||
-			ch == '_' || ch == '*' || ch == '.') {
-			return p.sql[start:i], i - start
+// popTableName pops the table name of a DROP TABLE, TRUNCATE or ALTER TABLE statement
+func (p *parser) popTableName(statement string) error {
+	tableName := p.peekName()
+	if !p.isTableName(tableName) {
+		return expectedErrorf([]string{"table"}, "at %s: expected table name", statement)
+	}
+	p.query.TableName = tableName
+	p.popName()
+	return nil
+}
+
+// alterActions are the keywords starting the action of ALTER TABLE
+var alterActions = []string{"ADD", "DROP", "RENAME", "ALTER"}
+
+// parseAlterAction parses the action following ALTER TABLE name. COLUMN is optional after ADD, DROP,
+// RENAME and ALTER.
+func (p *parser) parseAlterAction() (AlterTable, error) {
+	alter := AlterTable{}
+	action := p.peek()
+	switch action {
+	case "ADD", "DROP", "RENAME", "ALTER":
+	case "":
+		return alter, expectedErrorf(alterActions, "at ALTER TABLE: expected action")
+	default:
+		return alter, expectedErrorf(alterActions, "at ALTER TABLE: unknown action")
+	}
+	p.pop()
+	if action == "RENAME" && p.peek() == "TO" {
+		p.pop()
+		alter.Action = RenameTable
+		alter.NewName = p.peekName()
+		if !p.isTableName(alter.NewName) {
+			return alter, expectedErrorf([]string{"table"}, "at ALTER TABLE: expected new table name")
+		}
+		p.popName()
+		return alter, nil
+	}
+	if p.peek() == "COLUMN" {
+		p.pop()
+	}
+	alter.Column.Name = p.peekName()
+	if !p.isName(alter.Column.Name) {
+		return alter, expectedErrorf([]string{"field"}, "at ALTER TABLE: expected column name")
+	}
+	p.popName()
+
+	switch action {
+	case "ADD":
+		alter.Action = AddColumn
+		if err := p.parseColumnType(&alter.Column); err != nil {
+			return alter, err
+		}
+		return alter, p.parseColumnConstraints(&alter.Column)
+	case "DROP":
+		alter.Action = DropColumn
+	case "RENAME":
+		alter.Action = RenameColumn
+		if p.peek() != "TO" {
+			return alter, expectedErrorf([]string{"TO"}, "at ALTER TABLE: expected TO")
+		}
+		p.pop()
+		alter.NewName = p.peekName()
+		if !p.isName(alter.NewName) {
+			return alter, expectedErrorf([]string{"field"}, "at ALTER TABLE: expected new column name")
+		}
+		p.popName()
+	case "ALTER":
+		alter.Action = AlterColumnType
+		if p.peek() != "TYPE" {
+			return alter, expectedErrorf([]string{"TYPE"}, "at ALTER TABLE: expected TYPE")
+		}
+		p.pop()
+		return alter, p.parseColumnType(&alter.Column)
+	}
+	return alter, nil
+}
+
+// tableConstraintKeywords are the keywords starting a table constraint in CREATE TABLE
+var tableConstraintKeywords = map[string]bool{
+	"CONSTRAINT": true, "PRIMARY KEY": true, "UNIQUE": true, "CHECK": true, "FOREIGN KEY": true,
+}
+
+// popCreateFieldsSeparator pops the comma or closing parenthesis after a column or table constraint
+func (p *parser) popCreateFieldsSeparator() error {
+	switch p.peek() {
+	case ",":
+		p.pop()
+		p.step = stepParseCreateFields
+	case ")":
+		p.pop()
+		p.step = stepStatementEnd
+	default:
+		return expectedErrorf([]string{",", ")"}, "syntax error, expect ')'")
+	}
+	return nil
+}
+
+// parseColumnConstraints parses the constraints following the type of a column, in any order
+func (p *parser) parseColumnConstraints(column *Column) error {
+	for {
+		switch p.peek() {
+		case "PRIMARY KEY":
+			p.pop()
+			column.PrimaryKey = true
+		case "NOT":
+			p.pop()
+			if p.peek() != "NULL" {
+				return expectedErrorf([]string{"NULL"}, "syntax error, expect NULL after NOT")
+			}
+			p.pop()
+			column.NotNull = true
+		case "UNIQUE":
+			p.pop()
+			column.Unique = true
+		case "DEFAULT":
+			p.pop()
+			if !p.startsValueExpr() {
+				return expectedErrorf([]string{"value"}, "syntax error, expect DEFAULT value")
+			}
+			value, err := p.parseValueExprIn("DEFAULT")
+			if err != nil {
+				return err
+			}
+			if fields := fieldRefs(value); len(fields) > 0 {
+				return fmt.Errorf("at DEFAULT: column %s can't be referenced in a DEFAULT value", fields[0])
+			}
+			column.Default = value
+		case "CHECK":
+			p.pop()
+			check, err := p.parseCheck()
+			if err != nil {
+				return err
+			}
+			column.Check = check
+		case "REFERENCES":
+			p.pop()
+			reference, err := p.parseReference()
+			if err != nil {
+				return err
+			}
+			column.References = &reference
+		default:
+			return nil
+		}
+	}
+}
+
+// parseTableConstraint parses a PRIMARY KEY, UNIQUE, CHECK or FOREIGN KEY constraint of CREATE TABLE,
+// optionally named with CONSTRAINT
+func (p *parser) parseTableConstraint() (TableConstraint, error) {
+	constraint := TableConstraint{}
+	if p.peek() == "CONSTRAINT" {
+		p.pop()
+		name := p.peekName()
+		if !p.isName(name) {
+			return constraint, expectedErrorf([]string{"name"}, "syntax error, expect constraint name")
+		}
+		constraint.Name = name
+		p.popName()
+	}
+	var err error
+	switch p.peek() {
+	case "PRIMARY KEY":
+		p.pop()
+		constraint.Type = PrimaryKeyConstraint
+		constraint.Columns, err = p.parseNameList()
+	case "UNIQUE":
+		p.pop()
+		constraint.Type = UniqueConstraint
+		constraint.Columns, err = p.parseNameList()
+	case "CHECK":
+		p.pop()
+		constraint.Type = CheckConstraint
+		constraint.Check, err = p.parseCheck()
+	case "FOREIGN KEY":
+		p.pop()
+		constraint.Type = ForeignKeyConstraint
+		constraint.Columns, err = p.parseNameList()
+		if err != nil {
+			return constraint, err
+		}
+		if p.peek() != "REFERENCES" {
+			return constraint, expectedErrorf([]string{"REFERENCES"}, "syntax error, expect REFERENCES")
+		}
+		p.pop()
+		var reference Reference
+		reference, err = p.parseReference()
+		constraint.References = &reference
+	default:
+		return constraint, expectedErrorf([]string{"PRIMARY KEY", "UNIQUE", "CHECK", "FOREIGN KEY"}, "syntax error, expect constraint")
+	}
+	return constraint, err
+}
+
+// parseCheck parses the parenthesized expression of a CHECK constraint
+func (p *parser) parseCheck() (Expr, error) {
+	if p.peek() != "(" {
+		return nil, expectedErrorf([]string{"("}, "at CHECK: expected opening parenthesis")
+	}
+	p.pop()
+	clause := p.clause
+	p.clause = "CHECK"
+	check, err := p.parseOrExpr()
+	p.clause = clause
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != ")" {
+		return nil, expectedErrorf([]string{")"}, "at CHECK: expected closing parenthesis")
+	}
+	p.pop()
+	return check, nil
+}
+
+// parseReference parses the table and parenthesized columns following REFERENCES
+func (p *parser) parseReference() (Reference, error) {
+	reference := Reference{Table: p.peekName()}
+	if !p.isTableName(reference.Table) {
+		return reference, expectedErrorf([]string{"table"}, "syntax error, expect referenced table")
+	}
+	p.popName()
+	var err error
+	reference.Columns, err = p.parseNameList()
+	return reference, err
+}
+
+// parseNameList parses a parenthesized, comma separated list of column names
+func (p *parser) parseNameList() ([]string, error) {
+	if p.peek() != "(" {
+		return nil, expectedErrorf([]string{"("}, "syntax error, expect '('")
+	}
+	p.pop()
+	names := []string{}
+	for {
+		name := p.peekName()
+		if !p.isName(name) {
+			return nil, expectedErrorf([]string{"field"}, "syntax error, expect filed name")
+		}
+		names = append(names, name)
+		p.popName()
+		if p.peek() != "," {
+			break
+		}
+		p.pop()
+	}
+	if p.peek() != ")" {
+		return nil, expectedErrorf([]string{",", ")"}, "syntax error, expect ')'")
+	}
+	p.pop()
+	return names, nil
+}
+
+// parseValueExpr parses an expression of literals, fields, function calls and operators by precedence
+// climbing: signs bind the tightest, then * / and %, then + and -, then ||
+func (p *parser) parseValueExpr() (ValueExpr, error) {
+	return p.parseBinaryExpr(Concat.precedence())
+}
+
+// parseValueExprIn parses an expression of the given clause, which error messages refer to
+func (p *parser) parseValueExprIn(clause string) (ValueExpr, error) {
+	saved := p.clause
+	p.clause = clause
+	defer func() { p.clause = saved }()
+	return p.parseValueExpr()
+}
+
+// parseBinaryExpr parses operands joined by operators binding at least as tight as minPrecedence.
+// Operators of the same precedence are left associative.
+func (p *parser) parseBinaryExpr(minPrecedence int) (ValueExpr, error) {
+	left, err := p.parseUnaryExpr()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		operator, ok := p.peekArithmeticOperator()
+		if !ok || operator.precedence() < minPrecedence {
+			return left, nil
+		}
+		p.pop()
+		right, err := p.parseBinaryExpr(operator.precedence() + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Operator: operator, Left: left, Right: right}
+	}
+}
+
+// parseUnaryExpr parses an operand, optionally signed
+func (p *parser) parseUnaryExpr() (ValueExpr, error) {
+	if operator, ok := p.peekArithmeticOperator(); ok && (operator == Subtract || operator == Add) {
+		p.pop()
+		expr, err := p.parseUnaryExpr()
+		if err != nil {
+			return nil, err
+		}
+		return &UnaryExpr{Operator: operator, Expr: expr}, nil
+	}
+	return p.parseValueOperand()
+}
+
+// peekArithmeticOperator returns the arithmetic operator at the next token
+func (p *parser) peekArithmeticOperator() (ArithmeticOperator, bool) {
+	if p.next().Kind != OperatorToken {
+		return UnknownArithmeticOperator, false
+	}
+	operator, ok := arithmeticOperators[p.peek()]
+	return operator, ok
+}
+
+// startsValueExpr reports whether the next token can start an expression
+func (p *parser) startsValueExpr() bool {
+	if _, ok := p.peekValue(); ok {
+		return true
+	}
+	if operator, ok := p.peekArithmeticOperator(); ok {
+		return operator == Subtract || operator == Add
+	}
+	return p.peek() == "(" || p.peekKeyword("CASE") || p.isName(p.peekName())
+}
+
+// parseValueOperand parses a literal, a field, a call of a registered function, a CASE or CAST
+// expression or a parenthesized expression, converted by any "::type" following it. Aggregate function
+// calls are allowed in SELECT and HAVING, and refer to the column holding their result.
+func (p *parser) parseValueOperand() (ValueExpr, error) {
+	expr, err := p.parsePrimaryExpr()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "::" && p.next().Kind == OperatorToken {
+		p.pop()
+		t, err := p.parseCastType()
+		if err != nil {
+			return nil, err
+		}
+		expr = &CastExpr{Expr: expr, Type: t}
+	}
+	return expr, nil
+}
+
+// parsePrimaryExpr parses an operand of an expression, see parseValueOperand
+func (p *parser) parsePrimaryExpr() (ValueExpr, error) {
+	if p.peek() == "(" && p.next().Kind == PunctuationToken {
+		p.pop()
+		expr, err := p.parseValueExpr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, expectedErrorf([]string{")"}, "at %s: expected closing parenthesis", p.clause)
+		}
+		p.pop()
+		return expr, nil
+	}
+	if p.peekKeyword("CASE") {
+		return p.parseCase()
+	}
+	if value, ok := p.peekValue(); ok {
+		p.pop()
+		return &Literal{Value: value}, nil
+	}
+	name := p.peekName()
+	if p.isQuoted() || !p.isName(name) {
+		return nil, expectedErrorf([]string{"value", "field"}, "at %s: expected quoted value", p.clause)
+	}
+	quoted := p.next().Quoted
+	p.pop()
+	if p.peek() != "(" {
+		return &FieldRef{Name: name, Quoted: quoted}, nil
+	}
+	if strings.EqualFold(name, "CAST") && !quoted {
+		return p.parseCast()
+	}
+	if aggregateFunction(name) != UnknownAggregate {
+		if p.clause != "SELECT" && p.clause != "HAVING" {
+			return nil, fmt.Errorf("at %s: aggregate function %s is not allowed in %s, only in the select list or HAVING", p.clause, strings.ToUpper(name), p.clause)
+		}
+		aggregate, err := p.parseAggregate(name)
+		if err != nil {
+			return nil, fmt.Errorf("at %s: %w", p.clause, err)
+		}
+		return &FieldRef{Name: aggregate.String()}, nil
+	}
+	function, ok := lookupFunction(name)
+	if !ok {
+		return nil, fmt.Errorf("at %s: unknown function %s", p.clause, strings.ToUpper(name))
+	}
+	p.pop()
+	call := &FuncCall{Name: name}
+	for p.peek() != ")" {
+		if len(call.Args) > 0 {
+			if p.peek() != "," {
+				return nil, expectedErrorf([]string{",", ")"}, "at %s: expected comma or closing parenthesis", p.clause)
+			}
+			p.pop()
+		}
+		arg, err := p.parseValueExpr()
+		if err != nil {
+			return nil, err
+		}
+		call.Args = append(call.Args, arg)
+	}
+	p.pop()
+	if err := function.checkArity(name, len(call.Args)); err != nil {
+		return nil, fmt.Errorf("at %s: %w", p.clause, err)
+	}
+	return call, nil
+}
+
+// parseCast parses the parenthesized "expr AS type" of a CAST whose name was just popped
+func (p *parser) parseCast() (ValueExpr, error) {
+	p.pop() // (
+	expr, err := p.parseValueExpr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.peekKeyword("AS") {
+		return nil, expectedErrorf([]string{"AS"}, "at %s CAST: expected AS", p.clause)
+	}
+	p.pop()
+	t, err := p.parseCastType()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != ")" {
+		return nil, expectedErrorf([]string{")"}, "at %s CAST: expected closing parenthesis", p.clause)
+	}
+	p.pop()
+	return &CastExpr{Expr: expr, Type: t}, nil
+}
+
+// parseCastType parses the type name of a CAST or "::"
+func (p *parser) parseCastType() (CastType, error) {
+	expected := []string{"INT", "FLOAT", "TEXT", "BOOL", "TIMESTAMP"}
+	name := p.peekName()
+	if p.next().Kind != IdentifierToken || p.next().Quoted {
+		return UnknownCastType, expectedErrorf(expected, "at %s: expected type", p.clause)
+	}
+	t, ok := castTypes[strings.ToUpper(name)]
+	if !ok {
+		return UnknownCastType, expectedErrorf(expected, "at %s: unknown type %s", p.clause, name)
+	}
+	p.pop()
+	return t, nil
+}
+
+// parseCase parses a CASE expression, which is simple when an operand follows CASE and searched when
+// conditions follow each WHEN
+func (p *parser) parseCase() (ValueExpr, error) {
+	p.pop() // CASE
+	p.caseDepth++
+	defer func() { p.caseDepth-- }()
+	e := &CaseExpr{}
+	if p.startsValueExpr() {
+		operand, err := p.parseValueExpr()
+		if err != nil {
+			return nil, err
+		}
+		e.Operand = operand
+	}
+	for p.peekKeyword("WHEN") {
+		p.pop()
+		var when When
+		var err error
+		if e.Operand != nil {
+			when.Value, err = p.parseValueExpr()
+		} else {
+			when.Condition, err = p.parseOrExpr()
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !p.peekKeyword("THEN") {
+			return nil, expectedErrorf([]string{"THEN"}, "at %s CASE: expected THEN", p.clause)
+		}
+		p.pop()
+		if when.Result, err = p.parseValueExpr(); err != nil {
+			return nil, err
+		}
+		e.Whens = append(e.Whens, when)
+	}
+	if len(e.Whens) == 0 {
+		return nil, expectedErrorf([]string{"WHEN"}, "at %s CASE: expected WHEN", p.clause)
+	}
+	if p.peekKeyword("ELSE") {
+		p.pop()
+		var err error
+		if e.Else, err = p.parseValueExpr(); err != nil {
+			return nil, err
 		}
 	}
-	return p.sql[start:], len(p.sql) - start
+	if !p.peekKeyword("END") {
+		return nil, expectedErrorf([]string{"WHEN", "ELSE", "END"}, "at %s CASE: expected END", p.clause)
+	}
+	p.pop()
+	return e, nil
 }
 
+// parseAggregate parses the parenthesized argument of an aggregate function call whose name was
+// just popped, and records the call in the query
+func (p *parser) parseAggregate(name string) (Aggregate, error) {
+	aggregate := Aggregate{Function: aggregateFunction(name)}
+	if aggregate.Function == UnknownAggregate {
+		return aggregate, fmt.Errorf("unknown aggregate function %s", strings.ToUpper(name))
+	}
+	p.pop() // (
+	field := p.peekName()
+	if p.isQuoted() || field != "*" && !p.isName(field) {
+		return aggregate, fmt.Errorf("expected field in %s()", aggregate.Function)
+	}
+	if field == "*" && aggregate.Function != Count {
+		return aggregate, fmt.Errorf("only COUNT accepts *")
+	}
+	aggregate.Field = field
+	aggregate.Quoted = p.next().Quoted
+	p.pop()
+	if p.peek() != ")" {
+		return aggregate, fmt.Errorf("expected closing parenthesis after %s", aggregate)
+	}
+	p.pop()
+	for _, a := range p.query.Aggregates {
+		if a == aggregate {
+			return aggregate, nil
+		}
+	}
+	p.query.Aggregates = append(p.query.Aggregates, aggregate)
+	return aggregate, nil
+}
+
+// popCount pops a non-negative integer, as used by LIMIT and OFFSET
+func (p *parser) popCount() (int, error) {
+	if p.isQuoted() {
+		return 0, expectedErrorf([]string{"number"}, "expected unquoted number")
+	}
+	count, err := strconv.Atoi(p.peek())
+	if err != nil || count < 0 {
+		return 0, expectedErrorf([]string{"number"}, "expected non-negative number")
+	}
+	p.pop()
+	return count, nil
+}
+
+// isQuoted reports whether the next token is a quoted string
+func (p *parser) isQuoted() bool {
+	return p.next().Kind == StringToken
+}
+
+// next returns the next token, or an empty token at the end of the query
+func (p *parser) next() Token {
+	if p.atEnd() {
+		return Token{Offset: len(p.sql)}
+	}
+	return p.tokens[p.i]
+}
+
+// atEnd reports whether all tokens have been popped
+func (p *parser) atEnd() bool {
+	return p.i >= len(p.tokens)
+}
+
+func (p *parser) peek() string {
+	return p.next().Value
+}
+
+// peekKeyword reports whether the next token is the keyword, and not a string or quoted identifier
+// with the same text
+func (p *parser) peekKeyword(keyword string) bool {
+	token := p.next()
+	return token.Kind == KeywordToken && token.Value == keyword
+}
+
+// peekPunctuation reports whether the next token is the punctuation, such as "(", and not a string or
+// a quoted identifier with the same value
+func (p *parser) peekPunctuation(punctuation string) bool {
+	token := p.next()
+	return token.Kind == PunctuationToken && token.Value == punctuation
+}
+
+// peekName returns the next token as a name: keywords keep the case they were written in, quoted
+// strings are unquoted
+func (p *parser) peekName() string {
+	if token := p.next(); token.Kind != KeywordToken {
+		return token.Value
+	}
+	return p.next().Text
+}
+
+// isName reports whether name, read from the next token, is a field name: a quoted identifier or a
+// name that isn't reserved
+func (p *parser) isName(name string) bool {
+	if token := p.next(); p.caseDepth > 0 && token.Kind == KeywordToken && caseKeywords[token.Value] {
+		return false
+	}
+	return p.next().Quoted && name != "" || isIdentifier(name)
+}
+
+// isTableName reports whether the next token, with value name, can be a table name: a name or, as for
+// CSV files such as 'logs/data.csv', a single quoted string
+func (p *parser) isTableName(name string) bool {
+	return p.isName(name) || p.isQuoted() && name != ""
+}
+
+// popName pops a table or field name, recording it in Query.QuotedNames if it is a quoted identifier
+func (p *parser) popName() {
+	if token := p.next(); token.Quoted {
+		p.markQuoted(token.Value)
+	}
+	p.pop()
+}
+
+// markQuoted records a name written as a quoted identifier in Query.QuotedNames
+func (p *parser) markQuoted(name string) {
+	if p.query.QuotedNames == nil {
+		p.query.QuotedNames = map[string]bool{}
+	}
+	p.query.QuotedNames[name] = true
+}
+
+// peekValue returns the literal at the next token: a quoted string, a number, TRUE, FALSE or NULL
+func (p *parser) peekValue() (Value, bool) {
+	token := p.next()
+	switch token.Kind {
+	case StringToken:
+		return NewString(token.Value), true
+	case NumberToken:
+		return parseNumber(token.Value)
+	case KeywordToken:
+		switch token.Value {
+		case "TRUE":
+			return NewBool(true), true
+		case "FALSE":
+			return NewBool(false), true
+		case "NULL":
+			return Value{}, true
+		}
+	}
+	return Value{}, false
+}
+
+// peekQuotedString returns the unquoted value of the next token if it is a quoted string
+func (p *parser) peekQuotedString() (string, bool) {
+	token := p.next()
+	return token.Value, token.Kind == StringToken
+}
+
+func (p *parser) pop() string {
+	peeked := p.peek()
+	if !p.atEnd() {
+		p.i++
+	}
+	return peeked
+}
+
+var reservedWords = []string{
+	"(", ")", ">=", "<=", "!=", ",", "=", ">", "<", "SELECT", "INSERT INTO", "VALUES", "UPDATE", "DELETE FROM",
+	"WHERE", "FROM", "SET", "AS", "CREATE TABLE", "LIKE", "NOT LIKE", "IN", "NOT IN", "TRUE", "FALSE", "NULL", "IS",
+	"BETWEEN", "NOT BETWEEN", "CASE",
+}
+
+// caseKeywords are the keywords of a CASE expression that, like in "SELECT end FROM t", are names
+// outside one
+var caseKeywords = map[string]bool{"WHEN": true, "THEN": true, "ELSE": true, "END": true}
+
+// conditionOperators are the operators accepted between the operands of a condition
+var conditionOperators = []string{"=", "!=", ">", ">=", "<", "<=", "LIKE", "NOT LIKE", "IN", "NOT IN", "BETWEEN", "NOT BETWEEN", "IS"}
+
 func (p *parser) validate() error {
-	if len(p.query.Conditions) == 0 && p.step == stepWhereField {
+	if p.query.Where == nil && p.step == stepWhereField {
 		return fmt.Errorf("at WHERE: empty WHERE clause")
 	}
 	if p.query.Type == UnknownType {
 		return fmt.Errorf("query type cannot be empty")
 	}
+	if p.query.TableName == "" {
+		return fmt.Errorf("table name cannot be empty")
+	}
 	if p.query.Type == Create {
+		if p.step != stepStatementEnd {
+			return expectedErrorf([]string{",", ")"}, "at CREATE TABLE: expected ')'")
+		}
+		if len(p.query.Columns) == 0 {
+			return fmt.Errorf("at CREATE TABLE: expected at least one column")
+		}
 		return nil
 	}
-	if p.query.TableName == "" {
-		return fmt.Errorf("table name cannot be empty")
+	if p.query.Type == Alter && p.query.Alter == nil {
+		return fmt.Errorf("at ALTER TABLE: expected action")
 	}
-	if len(p.query.Conditions) == 0 && (p.query.Type == Update || p.query.Type == Delete) {
+	if p.query.Where == nil && (p.query.Type == Update || p.query.Type == Delete) {
 		return fmt.Errorf("at WHERE: WHERE clause is mandatory for UPDATE & DELETE")
 	}
-	for _, c := range p.query.Conditions {
+	for _, c := range conditionsOf(p.query.Where) {
 		if c.Operator == UnknownOperator {
 			return fmt.Errorf("at WHERE: condition without operator")
 		}
@@ -557,11 +1568,14 @@ func (p *parser) validate() error {
 				return fmt.Errorf("at WHERE: IN/NOT IN condition without values")
 			}
 		} else {
-			if c.Operand2 == "" && c.Operand2IsField {
+			if c.Operand2 == "" && c.Operand2IsField || c.Operand3 == "" && c.Operand3IsField {
 				return fmt.Errorf("at WHERE: condition with empty right side operand")
 			}
 		}
 	}
+	if err := p.validateGrouping(); err != nil {
+		return err
+	}
 	if p.query.Type == Insert && len(p.query.Inserts) == 0 {
 		return fmt.Errorf("at INSERT INTO: need at least one row to insert")
 	}
@@ -575,13 +1589,49 @@ func (p *parser) validate() error {
 	return nil
 }
 
-func (p *parser) logError() {
-	if p.err == nil {
-		return
+// validateGrouping checks that a query with GROUP BY or aggregate functions only refers
+// to grouped fields and aggregates outside of the aggregate calls
+func (p *parser) validateGrouping() error {
+	if len(p.query.GroupBy) == 0 && len(p.query.Aggregates) == 0 {
+		if p.query.Having != nil {
+			return fmt.Errorf("at HAVING: HAVING requires GROUP BY or aggregate functions")
+		}
+		return nil
+	}
+	grouped := map[string]bool{}
+	for _, field := range p.query.GroupBy {
+		grouped[field] = true
+	}
+	for _, aggregate := range p.query.Aggregates {
+		grouped[aggregate.String()] = true
+	}
+	for _, field := range p.query.Fields {
+		if field == "*" {
+			return fmt.Errorf("at SELECT: cannot SELECT * with GROUP BY or aggregate functions")
+		}
+		fields := []string{field}
+		if expr, ok := p.query.FieldExprs[field]; ok {
+			fields = fieldRefs(expr)
+		}
+		for _, field := range fields {
+			if !grouped[field] {
+				return fmt.Errorf("at SELECT: field %s must appear in GROUP BY or be aggregated", field)
+			}
+		}
+	}
+	for _, orderBy := range p.query.OrderBy {
+		if !grouped[orderBy.Field] && !isAlias(p.query.Aliases, orderBy.Field) {
+			return fmt.Errorf("at ORDER BY: field %s must appear in GROUP BY or be aggregated", orderBy.Field)
+		}
 	}
-	fmt.Println(p.sql)
-	fmt.Println(strings.Repeat(" ", p.i) + "^")
-	fmt.Println(p.err)
+	for _, c := range conditionsOf(p.query.Having) {
+		for _, field := range c.fieldRefs() {
+			if !grouped[field] {
+				return fmt.Errorf("at HAVING: field %s must appear in GROUP BY or be aggregated", field)
+			}
+		}
+	}
+	return nil
 }
 
 func isIdentifier(s string) bool {
@@ -590,266 +1640,250 @@ func isIdentifier(s string) bool {
 			return false
 		}
 	}
-	matched, _ := regexp.MatchString("[a-zA-Z_][a-zA-Z_0-9]*", s)
-	return matched
+	return identifierRegexp.MatchString(s)
 }
 
-func isIdentifierOrAsterisk(s string) bool {
-	return isIdentifier(s) || s == "*"
-}
+// identifierRegexp matches the names that can be written without quotes
+var identifierRegexp = regexp.MustCompile(`^[\pL_][\pL\pN_.]*$`)
 
-func min(a, b int) int {
-	if a < b {
-		return a
+func isAlias(aliases map[string]string, s string) bool {
+	for _, alias := range aliases {
+		if alias == s {
+			return true
+		}
 	}
-	return b
+	return false
 }
 
-// FilterRecursive applies a SQL query to a map of data and returns a filtered map using recursion.
+// FilterRecursive applies a SQL query to a map of data and returns the filtered map. The name is kept
+// for compatibility: the WHERE clause is compiled to a Program and evaluated without recursion.
 // The data is expected to be a map where the key is a unique identifier (like an ID)
 // and the value is another map representing a row, with column names as keys and values of type any.
+// Use Compile to filter with the same query many times.
 func FilterRecursive(sql string, data map[string]map[string]any) (map[string]map[string]any, error) {
-	q, err := Parse(sql)
+	program, err := Compile(sql)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse SQL: %w", err)
+		return nil, err
 	}
+	return program.Filter(data), nil
+}
 
-	if q.Type != Select {
-		return nil, fmt.Errorf("only SELECT queries can be filtered")
+// FilterOrdered applies a SQL query to a map of data like FilterRecursive, and returns the matching rows
+// as a slice sorted by the ORDER BY clause and paged by LIMIT and OFFSET.
+// Rows that compare equal are kept in the order of their keys, so the result is deterministic.
+func FilterOrdered(sql string, data map[string]map[string]any) ([]map[string]any, error) {
+	q, err := Parse(sql)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SQL: %w", err)
 	}
 
-	filteredData := make(map[string]map[string]any)
-
-	// Recursively filter each row
-	for key, row := range data {
-		if evaluateConditionsRecursive(row, q.Conditions, 0) {
-			filteredData[key] = row
-		}
+	program, err := CompileQuery(q)
+	if err != nil {
+		return nil, err
 	}
-
-	return filteredData, nil
+	rows := program.filterRows(data)
+	sortRows(rows, orderByFields(q), program.fields)
+	return pageRows(rows, q), nil
 }
 
-// evaluateConditionsRecursive recursively evaluates all conditions using AND logic
-// conditionIndex represents the current condition being evaluated
-func evaluateConditionsRecursive(row map[string]any, conditions []Condition, conditionIndex int) bool {
-	// Base case: if we've evaluated all conditions successfully, return true
-	if conditionIndex >= len(conditions) {
-		return true
+// FilterProjected applies a SELECT query to a map of data and returns its result set: the rows matching
+// the WHERE clause, sorted by ORDER BY, paged by LIMIT and OFFSET, and reduced to the SELECTed fields.
+// Fields are named by their alias if any and otherwise by their name, dotted paths resolve into nested
+// maps and fields missing from a row are nil, as are computed fields that can't be computed, such as
+// failed conversions. "*" keeps every field of the row.
+// Queries with GROUP BY or aggregate functions are executed by FilterAggregate.
+func FilterProjected(sql string, data map[string]map[string]any) ([]map[string]any, error) {
+	q, err := Parse(sql)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SQL: %w", err)
 	}
 
-	// Evaluate the current condition
-	currentCondition := conditions[conditionIndex]
-	if !evaluateConditionRecursive(row, currentCondition) {
-		// If current condition fails, short-circuit and return false
-		return false
+	program, err := CompileQuery(q)
+	if err != nil {
+		return nil, err
 	}
-
-	// Recursively evaluate the next condition
-	return evaluateConditionsRecursive(row, conditions, conditionIndex+1)
+	return program.project(data), nil
 }
 
-// evaluateConditionRecursive recursively evaluates a single condition
-func evaluateConditionRecursive(row map[string]any, cond Condition) bool {
-	// Get the field value using recursive field access
-	value, exists := getFieldValueRecursive(row, strings.Split(cond.Operand1, "."), 0)
-	if !exists {
-		return false
-	}
-
-	// Handle different operators recursively
-	return evaluateOperatorRecursive(value, cond)
+// project executes the SELECT query of a program, see FilterProjected
+func (p *Program) project(data map[string]map[string]any) []map[string]any {
+	return p.selectRows(p.filterRows(data))
 }
 
-// getFieldValueRecursive recursively accesses nested fields using dot notation
-// fieldParts contains the field path split by dots, partIndex is the current part being accessed
-func getFieldValueRecursive(data map[string]any, fieldParts []string, partIndex int) (any, bool) {
-	// Base case: we've reached the final field part
-	if partIndex >= len(fieldParts) {
-		return nil, false
+// filterRows returns the rows matching the WHERE clause of a program, in the order of their keys
+func (p *Program) filterRows(data map[string]map[string]any) []map[string]any {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
 	}
+	sort.Strings(keys)
 
-	currentPart := fieldParts[partIndex]
-	value, exists := data[currentPart]
-	if !exists {
-		return nil, false
-	}
-
-	// Base case: this is the last part, return the value
-	if partIndex == len(fieldParts)-1 {
-		return value, true
+	rows := []map[string]any{}
+	for _, key := range keys {
+		if p.where.matches(data[key]) {
+			rows = append(rows, data[key])
+		}
 	}
+	return rows
+}
 
-	// Recursive case: continue with nested map
-	nestedMap, ok := value.(map[string]any)
-	if !ok {
-		return nil, false
+// selectRows turns the rows matching the WHERE clause of a SELECT query into its result set:
+// grouped, sorted, paged and projected
+func (p *Program) selectRows(rows []map[string]any) []map[string]any {
+	q := p.query
+	if len(q.GroupBy) > 0 || len(q.Aggregates) > 0 {
+		return p.aggregate(rows)
 	}
 
-	return getFieldValueRecursive(nestedMap, fieldParts, partIndex+1)
-}
+	sortRows(rows, orderByFields(q), p.fields)
+	rows = pageRows(rows, q)
 
-// evaluateOperatorRecursive recursively evaluates different operators
-func evaluateOperatorRecursive(value any, cond Condition) bool {
-	switch cond.Operator {
-	case Eq:
-		return compareValuesRecursive(value, cond.Operand2, "eq")
-	case Ne:
-		return !compareValuesRecursive(value, cond.Operand2, "eq")
-	case Gt:
-		return compareValuesRecursive(value, cond.Operand2, "gt")
-	case Gte:
-		return compareValuesRecursive(value, cond.Operand2, "gte")
-	case Lt:
-		return compareValuesRecursive(value, cond.Operand2, "lt")
-	case Lte:
-		return compareValuesRecursive(value, cond.Operand2, "lte")
-	case Like:
-		return evaluateLikeRecursive(value, cond.Operand2)
-	case NotLike:
-		return !evaluateLikeRecursive(value, cond.Operand2)
-	case In:
-		return evaluateInRecursive(value, cond.InValues, 0)
-	case NotIn:
-		return !evaluateInRecursive(value, cond.InValues, 0)
-	default:
-		return false
+	result := make([]map[string]any, len(rows))
+	for i, row := range rows {
+		result[i] = projectRow(row, q, p.fields)
 	}
+	return result
 }
 
-// compareValuesRecursive recursively compares two values based on operation type
-func compareValuesRecursive(value any, operand2 string, operation string) bool {
-	// Try numeric comparison first
-	if numResult, ok := compareNumericRecursive(value, operand2, operation); ok {
-		return numResult
+// orderByFields returns the ORDER BY keys of a query with aliases replaced by the fields they name, as
+// aliases only exist once rows are projected
+func orderByFields(q Query) []OrderBy {
+	orderBy := make([]OrderBy, len(q.OrderBy))
+	for i, o := range q.OrderBy {
+		orderBy[i] = o
+		for field, alias := range q.Aliases {
+			if alias == o.Field {
+				orderBy[i].Field = field
+			}
+		}
 	}
-
-	// Fall back to string comparison
-	return compareStringRecursive(fmt.Sprintf("%v", value), operand2, operation)
+	return orderBy
 }
 
-// compareNumericRecursive attempts numeric comparison recursively
-func compareNumericRecursive(value any, operand2 string, operation string) (bool, bool) {
-	// Try to convert value to float64
-	var numValue float64
-	var err error
-
-	switch v := value.(type) {
-	case int:
-		numValue = float64(v)
-	case int64:
-		numValue = float64(v)
-	case float32:
-		numValue = float64(v)
-	case float64:
-		numValue = v
-	case string:
-		numValue, err = strconv.ParseFloat(v, 64)
-		if err != nil {
-			return false, false // Not a number
+// projectRow reduces a row to the SELECTed fields, renamed by their aliases, computing the fields that
+// are expressions
+func projectRow(row map[string]any, q Query, fields map[string]valueFunc) map[string]any {
+	projected := map[string]any{}
+	for _, field := range q.Fields {
+		if field == "*" {
+			for name, value := range row {
+				projected[name] = value
+			}
+			continue
 		}
-	default:
-		return false, false // Cannot convert to number
+		name := field
+		if alias, ok := q.Aliases[field]; ok {
+			name = alias
+		}
+		value, _ := fieldValue(row, field, fields)
+		projected[name] = value
 	}
+	return projected
+}
 
-	// Try to convert operand2 to float64
-	numOperand2, err := strconv.ParseFloat(operand2, 64)
-	if err != nil {
-		return false, false // operand2 is not a number
-	}
-
-	// Perform numeric comparison recursively
-	return performNumericComparisonRecursive(numValue, numOperand2, operation), true
-}
-
-// performNumericComparisonRecursive performs the actual numeric comparison
-func performNumericComparisonRecursive(val1, val2 float64, operation string) bool {
-	switch operation {
-	case "eq":
-		return val1 == val2
-	case "gt":
-		return val1 > val2
-	case "gte":
-		return val1 >= val2
-	case "lt":
-		return val1 < val2
-	case "lte":
-		return val1 <= val2
-	default:
-		return false
+// fieldValue returns the value of a SELECTed field of a row, computing it if it is one of the compiled
+// fields
+func fieldValue(row map[string]any, field string, fields map[string]valueFunc) (any, bool) {
+	if value, ok := fields[field]; ok {
+		return value.operand(row), true
 	}
+	return getFieldValue(row, field)
 }
 
-// compareStringRecursive performs string comparison recursively
-func compareStringRecursive(val1, val2 string, operation string) bool {
-	switch operation {
-	case "eq":
-		return val1 == val2
-	case "gt":
-		return val1 > val2
-	case "gte":
-		return val1 >= val2
-	case "lt":
-		return val1 < val2
-	case "lte":
-		return val1 <= val2
-	default:
-		return false
+// sortRows stably sorts rows by the given ORDER BY keys, which may be compiled fields
+func sortRows(rows []map[string]any, orderBy []OrderBy, fields map[string]valueFunc) {
+	if len(orderBy) == 0 {
+		return
 	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		for _, o := range orderBy {
+			value1, exists1 := fieldValue(rows[i], o.Field, fields)
+			value2, exists2 := fieldValue(rows[j], o.Field, fields)
+			cmp := compareSortValues(value1, exists1, value2, exists2)
+			if cmp == 0 {
+				continue
+			}
+			if o.Desc {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+		return false
+	})
 }
 
-// evaluateLikeRecursive recursively evaluates LIKE pattern matching
-func evaluateLikeRecursive(value any, pattern string) bool {
-	stringValue, ok := value.(string)
-	if !ok {
-		return false
+// compareSortValues returns -1, 0 or 1, comparing numerically when both values are numbers or numeric
+// strings, and as strings otherwise. Missing values sort before any present value
+func compareSortValues(value1 any, exists1 bool, value2 any, exists2 bool) int {
+	switch {
+	case !exists1 && !exists2:
+		return 0
+	case !exists1:
+		return -1
+	case !exists2:
+		return 1
 	}
-
-	// Convert SQL LIKE pattern to regex recursively
-	regexPattern := convertLikePatternRecursive(pattern, 0, "")
-	matched, err := regexp.MatchString("^"+regexPattern+"$", stringValue)
-	if err != nil {
-		return false
+	if number1, ok := toFloat64(value1); ok {
+		if number2, ok := toFloat64(value2); ok {
+			return compareFloat64(number1, number2)
+		}
 	}
-	return matched
+	return strings.Compare(fmt.Sprintf("%v", value1), fmt.Sprintf("%v", value2))
 }
 
-// convertLikePatternRecursive recursively converts SQL LIKE pattern to regex
-func convertLikePatternRecursive(pattern string, index int, result string) string {
-	// Base case: we've processed the entire pattern
-	if index >= len(pattern) {
-		return result
+// pageRows applies the OFFSET and LIMIT of a query to sorted rows
+func pageRows(rows []map[string]any, q Query) []map[string]any {
+	if q.Offset >= len(rows) {
+		return rows[:0]
 	}
-
-	currentChar := pattern[index]
-	switch currentChar {
-	case '%':
-		// % matches zero or more characters
-		return convertLikePatternRecursive(pattern, index+1, result+".*")
-	case '_':
-		// _ matches exactly one character
-		return convertLikePatternRecursive(pattern, index+1, result+".")
-	case '.', '*', '+', '?', '^', '$', '(', ')', '[', ']', '{', '}', '|', '\\':
-		// Escape special regex characters
-		return convertLikePatternRecursive(pattern, index+1, result+"\\"+string(currentChar))
-	default:
-		// Regular character
-		return convertLikePatternRecursive(pattern, index+1, result+string(currentChar))
+	rows = rows[q.Offset:]
+	if q.HasLimit && q.Limit < len(rows) {
+		rows = rows[:q.Limit]
 	}
+	return rows
 }
 
-// evaluateInRecursive recursively evaluates IN operator
-func evaluateInRecursive(value any, inValues []string, index int) bool {
-	// Base case: we've checked all values and found no match
-	if index >= len(inValues) {
-		return false
+// getFieldValue looks a field up by its exact name first, so that keys containing dots such as
+// "AVG(a.b)" resolve, and then as a dot separated path into nested maps
+func getFieldValue(row map[string]any, field string) (any, bool) {
+	if value, exists := row[field]; exists {
+		return value, true
 	}
-
-	// Check if current value matches
-	if fmt.Sprintf("%v", value) == inValues[index] {
-		return true
+	var value any = row
+	for {
+		nested, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		part := field
+		dot := strings.IndexByte(field, '.')
+		if dot >= 0 {
+			part, field = field[:dot], field[dot+1:]
+		}
+		if value, ok = nested[part]; !ok || dot < 0 {
+			return value, ok
+		}
 	}
+}
 
-	// Recursively check the next value
-	return evaluateInRecursive(value, inValues, index+1)
+// toFloat64 converts numbers and numeric strings to float64
+func toFloat64(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		numValue, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, false // Not a number
+		}
+		return numValue, true
+	default:
+		return 0, false
+	}
 }
@@ -98,92 +98,92 @@ func TestSQL(t *testing.T) {
 		{
 			Name: "SELECT with WHERE with = works",
 			SQL:  "SELECT a, c, d FROM 'b' WHERE a = ''",
-			Expected: Query{
+			Expected: withWhere(Query{
 				Type:      Select,
 				TableName: "b",
 				Fields:    []string{"a", "c", "d"},
 				Conditions: []Condition{
-					{Operand1: "a", Operand1IsField: true, Operator: Eq, Operand2: "", Operand2IsField: false},
+					{Operand1: "a", Operand1IsField: true, Operator: Eq, Operand2: "", Operand2IsField: false, Operand2Value: NewString("")},
 				},
-			},
+			}),
 			Err: nil,
 		},
 		{
 			Name: "SELECT with WHERE with < works",
 			SQL:  "SELECT a, c, d FROM 'b' WHERE a < '1'",
-			Expected: Query{
+			Expected: withWhere(Query{
 				Type:      Select,
 				TableName: "b",
 				Fields:    []string{"a", "c", "d"},
 				Conditions: []Condition{
-					{Operand1: "a", Operand1IsField: true, Operator: Lt, Operand2: "1", Operand2IsField: false},
+					{Operand1: "a", Operand1IsField: true, Operator: Lt, Operand2: "1", Operand2IsField: false, Operand2Value: NewString("1")},
 				},
-			},
+			}),
 			Err: nil,
 		},
 		{
 			Name: "SELECT with WHERE with <= works",
 			SQL:  "SELECT a, c, d FROM 'b' WHERE a <= '1'",
-			Expected: Query{
+			Expected: withWhere(Query{
 				Type:      Select,
 				TableName: "b",
 				Fields:    []string{"a", "c", "d"},
 				Conditions: []Condition{
-					{Operand1: "a", Operand1IsField: true, Operator: Lte, Operand2: "1", Operand2IsField: false},
+					{Operand1: "a", Operand1IsField: true, Operator: Lte, Operand2: "1", Operand2IsField: false, Operand2Value: NewString("1")},
 				},
-			},
+			}),
 			Err: nil,
 		},
 		{
 			Name: "SELECT with WHERE with > works",
 			SQL:  "SELECT a, c, d FROM 'b' WHERE a > '1'",
-			Expected: Query{
+			Expected: withWhere(Query{
 				Type:      Select,
 				TableName: "b",
 				Fields:    []string{"a", "c", "d"},
 				Conditions: []Condition{
-					{Operand1: "a", Operand1IsField: true, Operator: Gt, Operand2: "1", Operand2IsField: false},
+					{Operand1: "a", Operand1IsField: true, Operator: Gt, Operand2: "1", Operand2IsField: false, Operand2Value: NewString("1")},
 				},
-			},
+			}),
 			Err: nil,
 		},
 		{
 			Name: "SELECT with WHERE with >= works",
 			SQL:  "SELECT a, c, d FROM 'b' WHERE a >= '1'",
-			Expected: Query{
+			Expected: withWhere(Query{
 				Type:      Select,
 				TableName: "b",
 				Fields:    []string{"a", "c", "d"},
 				Conditions: []Condition{
-					{Operand1: "a", Operand1IsField: true, Operator: Gte, Operand2: "1", Operand2IsField: false},
+					{Operand1: "a", Operand1IsField: true, Operator: Gte, Operand2: "1", Operand2IsField: false, Operand2Value: NewString("1")},
 				},
-			},
+			}),
 			Err: nil,
 		},
 		{
 			Name: "SELECT with WHERE with != works",
 			SQL:  "SELECT a, c, d FROM 'b' WHERE a != '1'",
-			Expected: Query{
+			Expected: withWhere(Query{
 				Type:      Select,
 				TableName: "b",
 				Fields:    []string{"a", "c", "d"},
 				Conditions: []Condition{
-					{Operand1: "a", Operand1IsField: true, Operator: Ne, Operand2: "1", Operand2IsField: false},
+					{Operand1: "a", Operand1IsField: true, Operator: Ne, Operand2: "1", Operand2IsField: false, Operand2Value: NewString("1")},
 				},
-			},
+			}),
 			Err: nil,
 		},
 		{
 			Name: "SELECT with WHERE with != works (comparing field against another field)",
 			SQL:  "SELECT a, c, d FROM 'b' WHERE a != b",
-			Expected: Query{
+			Expected: withWhere(Query{
 				Type:      Select,
 				TableName: "b",
 				Fields:    []string{"a", "c", "d"},
 				Conditions: []Condition{
 					{Operand1: "a", Operand1IsField: true, Operator: Ne, Operand2: "b", Operand2IsField: true},
 				},
-			},
+			}),
 			Err: nil,
 		},
 		{
@@ -211,17 +211,219 @@ func TestSQL(t *testing.T) {
 		{
 			Name: "SELECT with WHERE with two conditions using AND works",
 			SQL:  "SELECT a, c, d FROM 'b' WHERE a != '1' AND b = '2'",
-			Expected: Query{
+			Expected: withWhere(Query{
 				Type:      Select,
 				TableName: "b",
 				Fields:    []string{"a", "c", "d"},
 				Conditions: []Condition{
-					{Operand1: "a", Operand1IsField: true, Operator: Ne, Operand2: "1", Operand2IsField: false},
-					{Operand1: "b", Operand1IsField: true, Operator: Eq, Operand2: "2", Operand2IsField: false},
+					{Operand1: "a", Operand1IsField: true, Operator: Ne, Operand2: "1", Operand2IsField: false, Operand2Value: NewString("1")},
+					{Operand1: "b", Operand1IsField: true, Operator: Eq, Operand2: "2", Operand2IsField: false, Operand2Value: NewString("2")},
+				},
+			}),
+			Err: nil,
+		},
+		{
+			Name: "SELECT with WHERE with OR works",
+			SQL:  "SELECT a FROM 'b' WHERE a = '1' OR b > '2'",
+			Expected: Query{
+				Type:      Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Where: &OrExpr{
+					Left:  Condition{Operand1: "a", Operand1IsField: true, Operator: Eq, Operand2: "1", Operand2Value: NewString("1")},
+					Right: Condition{Operand1: "b", Operand1IsField: true, Operator: Gt, Operand2: "2", Operand2Value: NewString("2")},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with WHERE with AND binding tighter than OR works",
+			SQL:  "SELECT a FROM 'b' WHERE a = '1' OR b > '2' AND c = '3'",
+			Expected: Query{
+				Type:      Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Where: &OrExpr{
+					Left: Condition{Operand1: "a", Operand1IsField: true, Operator: Eq, Operand2: "1", Operand2Value: NewString("1")},
+					Right: &AndExpr{
+						Left:  Condition{Operand1: "b", Operand1IsField: true, Operator: Gt, Operand2: "2", Operand2Value: NewString("2")},
+						Right: Condition{Operand1: "c", Operand1IsField: true, Operator: Eq, Operand2: "3", Operand2Value: NewString("3")},
+					},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with WHERE with NOT and parentheses works",
+			SQL:  "SELECT a FROM 'b' WHERE a = '1' OR (b > '2' AND NOT c LIKE 'x%')",
+			Expected: Query{
+				Type:      Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Where: &OrExpr{
+					Left: Condition{Operand1: "a", Operand1IsField: true, Operator: Eq, Operand2: "1", Operand2Value: NewString("1")},
+					Right: &ParenExpr{Expr: &AndExpr{
+						Left:  Condition{Operand1: "b", Operand1IsField: true, Operator: Gt, Operand2: "2", Operand2Value: NewString("2")},
+						Right: &NotExpr{Expr: Condition{Operand1: "c", Operand1IsField: true, Operator: Like, Operand2: "x%", Operand2Value: NewString("x%")}},
+					}},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with WHERE with unclosed parenthesis fails",
+			SQL:      "SELECT a FROM 'b' WHERE (a = '1' OR b = '2'",
+			Expected: Query{},
+			Err:      fmt.Errorf("at WHERE: expected closing parenthesis"),
+		},
+		{
+			Name:     "SELECT with WHERE with dangling OR fails",
+			SQL:      "SELECT a FROM 'b' WHERE a = '1' OR",
+			Expected: Query{},
+			Err:      fmt.Errorf("at WHERE: expected field"),
+		},
+		{
+			Name: "SELECT with ORDER BY works",
+			SQL:  "SELECT a, c FROM 'b' ORDER BY a DESC, c",
+			Expected: Query{
+				Type:      Select,
+				TableName: "b",
+				Fields:    []string{"a", "c"},
+				OrderBy:   []OrderBy{{Field: "a", Desc: true}, {Field: "c"}},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with WHERE, ORDER BY, LIMIT and OFFSET works",
+			SQL:  "SELECT * FROM 'b' WHERE a = '1' ORDER BY c ASC LIMIT 10 OFFSET 20",
+			Expected: withWhere(Query{
+				Type:      Select,
+				TableName: "b",
+				Fields:    []string{"*"},
+				Conditions: []Condition{
+					{Operand1: "a", Operand1IsField: true, Operator: Eq, Operand2: "1", Operand2IsField: false, Operand2Value: NewString("1")},
+				},
+				OrderBy:  []OrderBy{{Field: "c"}},
+				Limit:    10,
+				HasLimit: true,
+				Offset:   20,
+			}),
+			Err: nil,
+		},
+		{
+			Name: "SELECT with LIMIT 0 works",
+			SQL:  "SELECT a FROM 'b' LIMIT 0",
+			Expected: Query{
+				Type:      Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Limit:     0,
+				HasLimit:  true,
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with ORDER without BY fails",
+			SQL:      "SELECT a FROM 'b' ORDER a",
+			Expected: Query{},
+			Err:      fmt.Errorf("at ORDER BY: expected BY"),
+		},
+		{
+			Name:     "SELECT with empty ORDER BY fails",
+			SQL:      "SELECT a FROM 'b' ORDER BY",
+			Expected: Query{},
+			Err:      fmt.Errorf("at ORDER BY: expected field"),
+		},
+		{
+			Name:     "SELECT with negative LIMIT fails",
+			SQL:      "SELECT a FROM 'b' LIMIT -1",
+			Expected: Query{},
+			Err:      fmt.Errorf("at LIMIT: expected non-negative number"),
+		},
+		{
+			Name:     "SELECT with LIMIT after OFFSET fails",
+			SQL:      "SELECT a FROM 'b' OFFSET 1 LIMIT 1",
+			Expected: Query{},
+			Err:      fmt.Errorf("at SELECT: unexpected 'LIMIT'"),
+		},
+		{
+			Name: "SELECT with aggregate functions, GROUP BY and HAVING works",
+			SQL:  "SELECT device, COUNT(*), avg(temp) AS t FROM 't' GROUP BY device HAVING COUNT(*) > 10 ORDER BY t DESC",
+			Expected: Query{
+				Type:      Select,
+				TableName: "t",
+				Fields:    []string{"device", "COUNT(*)", "AVG(temp)"},
+				Aliases:   map[string]string{"AVG(temp)": "t"},
+				GroupBy:   []string{"device"},
+				Having:    Condition{Operand1: "COUNT(*)", Operand1IsField: true, Operator: Gt, Operand2: "10", Operand2Value: NewInt(10)},
+				Aggregates: []Aggregate{
+					{Function: Count, Field: "*"},
+					{Function: Avg, Field: "temp"},
 				},
+				OrderBy: []OrderBy{{Field: "t", Desc: true}},
 			},
 			Err: nil,
 		},
+		{
+			Name: "SELECT with aggregate functions without GROUP BY works",
+			SQL:  "SELECT MIN(ts), MAX(ts), SUM(bytes) FROM 't' WHERE device = 'a'",
+			Expected: withWhere(Query{
+				Type:      Select,
+				TableName: "t",
+				Fields:    []string{"MIN(ts)", "MAX(ts)", "SUM(bytes)"},
+				Conditions: []Condition{
+					{Operand1: "device", Operand1IsField: true, Operator: Eq, Operand2: "a", Operand2IsField: false, Operand2Value: NewString("a")},
+				},
+				Aggregates: []Aggregate{
+					{Function: Min, Field: "ts"},
+					{Function: Max, Field: "ts"},
+					{Function: Sum, Field: "bytes"},
+				},
+			}),
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with field neither grouped nor aggregated fails",
+			SQL:      "SELECT a, COUNT(*) FROM 't'",
+			Expected: Query{},
+			Err:      fmt.Errorf("at SELECT: field a must appear in GROUP BY or be aggregated"),
+		},
+		{
+			Name:     "SELECT with unknown function fails",
+			SQL:      "SELECT FOO(a) FROM 't'",
+			Expected: Query{},
+			Err:      fmt.Errorf("at SELECT: unknown function FOO"),
+		},
+		{
+			Name:     "SELECT with SUM(*) fails",
+			SQL:      "SELECT SUM(*) FROM 't'",
+			Expected: Query{},
+			Err:      fmt.Errorf("at SELECT: only COUNT accepts *"),
+		},
+		{
+			Name:     "SELECT with aggregate function in WHERE fails",
+			SQL:      "SELECT a FROM 't' WHERE COUNT(*) > 1",
+			Expected: Query{},
+			Err:      fmt.Errorf("at WHERE: aggregate function COUNT is not allowed in WHERE, only in the select list or HAVING"),
+		},
+		{
+			Name:     "UPDATE with aggregate function fails",
+			SQL:      "UPDATE 't' SET a = max(b) WHERE c = 1",
+			Expected: Query{Type: Update, TableName: "t"},
+			Err:      fmt.Errorf("at UPDATE: aggregate function MAX is not allowed in UPDATE, only in the select list or HAVING"),
+		},
+		{
+			Name:     "SELECT with HAVING without GROUP BY fails",
+			SQL:      "SELECT a FROM 't' HAVING a = '1'",
+			Expected: Query{},
+			Err:      fmt.Errorf("at HAVING: HAVING requires GROUP BY or aggregate functions"),
+		},
+		{
+			Name:     "SELECT with HAVING on field not grouped fails",
+			SQL:      "SELECT a, COUNT(*) FROM 't' GROUP BY a HAVING b = '1'",
+			Expected: Query{},
+			Err:      fmt.Errorf("at HAVING: field b must appear in GROUP BY or be aggregated"),
+		},
 		{
 			Name:     "Empty UPDATE fails",
 			SQL:      "UPDATE",
@@ -267,56 +469,337 @@ func TestSQL(t *testing.T) {
 		{
 			Name: "UPDATE works",
 			SQL:  "UPDATE 'a' SET b = 'hello' WHERE a = '1'",
-			Expected: Query{
-				Type:      Update,
-				TableName: "a",
-				Updates:   map[string]string{"b": "hello"},
+			Expected: withWhere(Query{
+				Type:        Update,
+				TableName:   "a",
+				Assignments: []Assignment{{Field: "b", Value: &Literal{Value: NewString("hello")}}},
 				Conditions: []Condition{
-					{Operand1: "a", Operand1IsField: true, Operator: Eq, Operand2: "1", Operand2IsField: false},
+					{Operand1: "a", Operand1IsField: true, Operator: Eq, Operand2: "1", Operand2IsField: false, Operand2Value: NewString("1")},
 				},
-			},
+			}),
 			Err: nil,
 		},
 		{
 			Name: "UPDATE works with simple quote inside",
 			SQL:  "UPDATE 'a' SET b = 'hello\\'world' WHERE a = '1'",
-			Expected: Query{
-				Type:      Update,
-				TableName: "a",
-				Updates:   map[string]string{"b": "hello\\'world"},
+			Expected: withWhere(Query{
+				Type:        Update,
+				TableName:   "a",
+				Assignments: []Assignment{{Field: "b", Value: &Literal{Value: NewString("hello\\'world")}}},
 				Conditions: []Condition{
-					{Operand1: "a", Operand1IsField: true, Operator: Eq, Operand2: "1", Operand2IsField: false},
+					{Operand1: "a", Operand1IsField: true, Operator: Eq, Operand2: "1", Operand2IsField: false, Operand2Value: NewString("1")},
 				},
-			},
+			}),
 			Err: nil,
 		},
 		{
 			Name: "UPDATE with multiple SETs works",
 			SQL:  "UPDATE 'a' SET b = 'hello', c = 'bye' WHERE a = '1'",
-			Expected: Query{
-				Type:      Update,
-				TableName: "a",
-				Updates:   map[string]string{"b": "hello", "c": "bye"},
+			Expected: withWhere(Query{
+				Type:        Update,
+				TableName:   "a",
+				Assignments: []Assignment{{Field: "b", Value: &Literal{Value: NewString("hello")}}, {Field: "c", Value: &Literal{Value: NewString("bye")}}},
 				Conditions: []Condition{
-					{Operand1: "a", Operand1IsField: true, Operator: Eq, Operand2: "1", Operand2IsField: false},
+					{Operand1: "a", Operand1IsField: true, Operator: Eq, Operand2: "1", Operand2IsField: false, Operand2Value: NewString("1")},
 				},
-			},
+			}),
 			Err: nil,
 		},
 		{
 			Name: "UPDATE with multiple SETs and multiple conditions works",
 			SQL:  "UPDATE 'a' SET b = 'hello', c = 'bye' WHERE a = '1' AND b = '789'",
-			Expected: Query{
+			Expected: withWhere(Query{
+				Type:        Update,
+				TableName:   "a",
+				Assignments: []Assignment{{Field: "b", Value: &Literal{Value: NewString("hello")}}, {Field: "c", Value: &Literal{Value: NewString("bye")}}},
+				Conditions: []Condition{
+					{Operand1: "a", Operand1IsField: true, Operator: Eq, Operand2: "1", Operand2IsField: false, Operand2Value: NewString("1")},
+					{Operand1: "b", Operand1IsField: true, Operator: Eq, Operand2: "789", Operand2IsField: false, Operand2Value: NewString("789")},
+				},
+			}),
+			Err: nil,
+		},
+		{
+			Name: "UPDATE with expression values keeps the assignments in order",
+			SQL:  "UPDATE 'a' SET count = count + 1, ts = NOW(), name = other_col WHERE id = 1",
+			Expected: withWhere(Query{
+				Type:      Update,
+				TableName: "a",
+				Assignments: []Assignment{
+					{Field: "count", Value: &BinaryExpr{Operator: Add, Left: &FieldRef{Name: "count"}, Right: &Literal{Value: NewInt(1)}}},
+					{Field: "ts", Value: &FuncCall{Name: "NOW"}},
+					{Field: "name", Value: &FieldRef{Name: "other_col"}},
+				},
+				Conditions: []Condition{
+					{Operand1: "id", Operand1IsField: true, Operator: Eq, Operand2: "1", Operand2Value: NewInt(1)},
+				},
+			}),
+			Err: nil,
+		},
+		{
+			Name: "UPDATE with arithmetic respects precedence and parentheses",
+			SQL:  "UPDATE 'a' SET b = 2 * (c - 1) + d / 4 WHERE e = 1",
+			Expected: withWhere(Query{
 				Type:      Update,
 				TableName: "a",
-				Updates:   map[string]string{"b": "hello", "c": "bye"},
+				Assignments: []Assignment{{Field: "b", Value: &BinaryExpr{
+					Operator: Add,
+					Left:     &BinaryExpr{Operator: Multiply, Left: &Literal{Value: NewInt(2)}, Right: &BinaryExpr{Operator: Subtract, Left: &FieldRef{Name: "c"}, Right: &Literal{Value: NewInt(1)}}},
+					Right:    &BinaryExpr{Operator: Divide, Left: &FieldRef{Name: "d"}, Right: &Literal{Value: NewInt(4)}},
+				}}},
 				Conditions: []Condition{
-					{Operand1: "a", Operand1IsField: true, Operator: Eq, Operand2: "1", Operand2IsField: false},
-					{Operand1: "b", Operand1IsField: true, Operator: Eq, Operand2: "789", Operand2IsField: false},
+					{Operand1: "e", Operand1IsField: true, Operator: Eq, Operand2: "1", Operand2Value: NewInt(1)},
 				},
+			}),
+			Err: nil,
+		},
+		{
+			Name:     "UPDATE setting a column twice fails",
+			SQL:      "UPDATE 'a' SET b = 1, c = 2, b = 3",
+			Expected: Query{},
+			Err:      fmt.Errorf("at UPDATE: duplicate column b"),
+		},
+		{
+			Name:     "UPDATE with an unclosed parenthesis fails",
+			SQL:      "UPDATE 'a' SET b = (c + 1 WHERE d = 1",
+			Expected: Query{},
+			Err:      fmt.Errorf("at UPDATE: expected closing parenthesis"),
+		},
+		{
+			Name: "SELECT with arithmetic in WHERE works",
+			SQL:  "SELECT a FROM 'b' WHERE temp_f - 32 > 10 AND (c + 1) * 2 <= d",
+			Expected: withWhere(Query{
+				Type:      Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Conditions: []Condition{
+					{
+						Operand1:      "temp_f - 32",
+						Operand1Expr:  &BinaryExpr{Operator: Subtract, Left: &FieldRef{Name: "temp_f"}, Right: &Literal{Value: NewInt(32)}},
+						Operator:      Gt,
+						Operand2:      "10",
+						Operand2Value: NewInt(10),
+					},
+					{
+						Operand1: "(c + 1) * 2",
+						Operand1Expr: &BinaryExpr{
+							Operator: Multiply,
+							Left:     &BinaryExpr{Operator: Add, Left: &FieldRef{Name: "c"}, Right: &Literal{Value: NewInt(1)}},
+							Right:    &Literal{Value: NewInt(2)},
+						},
+						Operator:        Lte,
+						Operand2:        "d",
+						Operand2IsField: true,
+					},
+				},
+			}),
+			Err: nil,
+		},
+		{
+			Name: "SELECT with computed fields works",
+			SQL:  "SELECT price * qty AS total, name || '!' FROM 'b'",
+			Expected: Query{
+				Type:      Select,
+				TableName: "b",
+				Fields:    []string{"price * qty", "name || '!'"},
+				FieldExprs: map[string]ValueExpr{
+					"price * qty": &BinaryExpr{Operator: Multiply, Left: &FieldRef{Name: "price"}, Right: &FieldRef{Name: "qty"}},
+					"name || '!'": &BinaryExpr{Operator: Concat, Left: &FieldRef{Name: "name"}, Right: &Literal{Value: NewString("!")}},
+				},
+				Aliases: map[string]string{"price * qty": "total"},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with operators binds by precedence",
+			SQL:  "SELECT a FROM 'b' WHERE -a + b * c % 2 || 'x' = 'y'",
+			Expected: withWhere(Query{
+				Type:      Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Conditions: []Condition{
+					{
+						Operand1: "-a + b * c % 2 || 'x'",
+						Operand1Expr: &BinaryExpr{
+							Operator: Concat,
+							Left: &BinaryExpr{
+								Operator: Add,
+								Left:     &UnaryExpr{Operator: Subtract, Expr: &FieldRef{Name: "a"}},
+								Right: &BinaryExpr{
+									Operator: Modulo,
+									Left:     &BinaryExpr{Operator: Multiply, Left: &FieldRef{Name: "b"}, Right: &FieldRef{Name: "c"}},
+									Right:    &Literal{Value: NewInt(2)},
+								},
+							},
+							Right: &Literal{Value: NewString("x")},
+						},
+						Operator:      Eq,
+						Operand2:      "y",
+						Operand2Value: NewString("y"),
+					},
+				},
+			}),
+			Err: nil,
+		},
+		{
+			Name: "INSERT with expression values works",
+			SQL:  "INSERT INTO 'a' (b, c, d) VALUES (1 + 2 * 3, 'x' || 'y', -(1.5))",
+			Expected: Query{
+				Type:      Insert,
+				TableName: "a",
+				Fields:    []string{"b", "c", "d"},
+				Inserts: [][]ValueExpr{{
+					&BinaryExpr{Operator: Add, Left: &Literal{Value: NewInt(1)}, Right: &BinaryExpr{Operator: Multiply, Left: &Literal{Value: NewInt(2)}, Right: &Literal{Value: NewInt(3)}}},
+					&BinaryExpr{Operator: Concat, Left: &Literal{Value: NewString("x")}, Right: &Literal{Value: NewString("y")}},
+					&UnaryExpr{Operator: Subtract, Expr: &Literal{Value: NewFloat(1.5)}},
+				}},
 			},
 			Err: nil,
 		},
+		{
+			Name: "SELECT with function calls works",
+			SQL:  "SELECT LOWER(name) AS n FROM 'b' WHERE ROUND(temp, 1) > 20.5",
+			Expected: withWhere(Query{
+				Type:       Select,
+				TableName:  "b",
+				Fields:     []string{"LOWER(name)"},
+				FieldExprs: map[string]ValueExpr{"LOWER(name)": &FuncCall{Name: "LOWER", Args: []ValueExpr{&FieldRef{Name: "name"}}}},
+				Aliases:    map[string]string{"LOWER(name)": "n"},
+				Conditions: []Condition{
+					{
+						Operand1:      "ROUND(temp, 1)",
+						Operand1Expr:  &FuncCall{Name: "ROUND", Args: []ValueExpr{&FieldRef{Name: "temp"}, &Literal{Value: NewInt(1)}}},
+						Operator:      Gt,
+						Operand2:      "20.5",
+						Operand2Value: NewFloat(20.5),
+					},
+				},
+			}),
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with a function call with too many arguments fails",
+			SQL:      "SELECT a FROM 'b' WHERE LOWER(a, b) = 'x'",
+			Expected: Query{},
+			Err:      fmt.Errorf("at WHERE: LOWER takes 1 argument(s), got 2"),
+		},
+		{
+			Name:     "INSERT with a value referring to a field fails",
+			SQL:      "INSERT INTO 'a' (b) VALUES (c + 1)",
+			Expected: Query{},
+			Err:      fmt.Errorf("at INSERT INTO: values cannot refer to field c"),
+		},
+		{
+			Name:     "SELECT with an unknown function fails",
+			SQL:      "SELECT a FROM 'b' WHERE FOO(a) = 1",
+			Expected: Query{},
+			Err:      fmt.Errorf("at WHERE: unknown function FOO"),
+		},
+		{
+			Name:     "SELECT with HAVING on an expression of a field not grouped fails",
+			SQL:      "SELECT a, COUNT(*) FROM 't' GROUP BY a HAVING COUNT(*) + b > 1",
+			Expected: Query{},
+			Err:      fmt.Errorf("at HAVING: field b must appear in GROUP BY or be aggregated"),
+		},
+		{
+			Name:     "SELECT with an expression of a field not grouped fails",
+			SQL:      "SELECT a * b, COUNT(*) FROM 't' GROUP BY a",
+			Expected: Query{},
+			Err:      fmt.Errorf("at SELECT: field b must appear in GROUP BY or be aggregated"),
+		},
+		{
+			Name:     "SELECT with a dangling operator fails",
+			SQL:      "SELECT a FROM 'b' WHERE a + = 1",
+			Expected: Query{},
+			Err:      fmt.Errorf("at WHERE: expected quoted value"),
+		},
+		{
+			Name: "SELECT with CASE expressions works",
+			SQL:  "SELECT CASE WHEN temp > 30 THEN 'hot' ELSE 'ok' END AS label FROM 'b' WHERE CASE code WHEN 1 THEN 'x' END = 'x'",
+			Expected: withWhere(Query{
+				Type:      Select,
+				TableName: "b",
+				Fields:    []string{"CASE WHEN temp > 30 THEN 'hot' ELSE 'ok' END"},
+				FieldExprs: map[string]ValueExpr{"CASE WHEN temp > 30 THEN 'hot' ELSE 'ok' END": &CaseExpr{
+					Whens: []When{{
+						Condition: Condition{Operand1: "temp", Operand1IsField: true, Operator: Gt, Operand2: "30", Operand2Value: NewInt(30)},
+						Result:    &Literal{Value: NewString("hot")},
+					}},
+					Else: &Literal{Value: NewString("ok")},
+				}},
+				Aliases: map[string]string{"CASE WHEN temp > 30 THEN 'hot' ELSE 'ok' END": "label"},
+				Conditions: []Condition{
+					{
+						Operand1: "CASE code WHEN 1 THEN 'x' END",
+						Operand1Expr: &CaseExpr{
+							Operand: &FieldRef{Name: "code"},
+							Whens:   []When{{Value: &Literal{Value: NewInt(1)}, Result: &Literal{Value: NewString("x")}}},
+						},
+						Operator:      Eq,
+						Operand2:      "x",
+						Operand2Value: NewString("x"),
+					},
+				},
+			}),
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with CASE without WHEN fails",
+			SQL:      "SELECT CASE a THEN 1 END FROM 'b'",
+			Expected: Query{},
+			Err:      fmt.Errorf("at SELECT CASE: expected WHEN"),
+		},
+		{
+			Name:     "SELECT with CASE without THEN fails",
+			SQL:      "SELECT a FROM 'b' WHERE CASE WHEN a = 1 ELSE 2 END = 1",
+			Expected: Query{},
+			Err:      fmt.Errorf("at WHERE CASE: expected THEN"),
+		},
+		{
+			Name:     "SELECT with CASE without END fails",
+			SQL:      "SELECT CASE WHEN a = 1 THEN 1 FROM 'b'",
+			Expected: Query{},
+			Err:      fmt.Errorf("at SELECT CASE: expected END"),
+		},
+		{
+			Name:     "SELECT with CASE on a field not grouped fails",
+			SQL:      "SELECT a, CASE WHEN b > 1 THEN COUNT(*) END FROM 't' GROUP BY a",
+			Expected: Query{},
+			Err:      fmt.Errorf("at SELECT: field b must appear in GROUP BY or be aggregated"),
+		},
+		{
+			Name: "SELECT with type conversions works",
+			SQL:  "SELECT CAST(temp AS FLOAT) AS t FROM 'b' WHERE code::INT = 1",
+			Expected: withWhere(Query{
+				Type:       Select,
+				TableName:  "b",
+				Fields:     []string{"CAST(temp AS FLOAT)"},
+				FieldExprs: map[string]ValueExpr{"CAST(temp AS FLOAT)": &CastExpr{Expr: &FieldRef{Name: "temp"}, Type: FloatType}},
+				Aliases:    map[string]string{"CAST(temp AS FLOAT)": "t"},
+				Conditions: []Condition{
+					{
+						Operand1:      "CAST(code AS INT)",
+						Operand1Expr:  &CastExpr{Expr: &FieldRef{Name: "code"}, Type: IntType},
+						Operator:      Eq,
+						Operand2:      "1",
+						Operand2Value: NewInt(1),
+					},
+				},
+			}),
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with CAST without AS fails",
+			SQL:      "SELECT CAST(a INT) FROM 'b'",
+			Expected: Query{},
+			Err:      fmt.Errorf("at SELECT CAST: expected AS"),
+		},
+		{
+			Name:     "SELECT with a conversion to an unknown type fails",
+			SQL:      "SELECT a FROM 'b' WHERE a::BLOB = 1",
+			Expected: Query{},
+			Err:      fmt.Errorf("at WHERE: unknown type BLOB"),
+		},
 		{
 			Name:     "Empty DELETE fails",
 			SQL:      "DELETE FROM",
@@ -344,13 +827,13 @@ func TestSQL(t *testing.T) {
 		{
 			Name: "DELETE with WHERE works",
 			SQL:  "DELETE FROM 'a' WHERE b = '1'",
-			Expected: Query{
+			Expected: withWhere(Query{
 				Type:      Delete,
 				TableName: "a",
 				Conditions: []Condition{
-					{Operand1: "b", Operand1IsField: true, Operator: Eq, Operand2: "1", Operand2IsField: false},
+					{Operand1: "b", Operand1IsField: true, Operator: Eq, Operand2: "1", Operand2IsField: false, Operand2Value: NewString("1")},
 				},
-			},
+			}),
 			Err: nil,
 		},
 		{
@@ -384,69 +867,483 @@ func TestSQL(t *testing.T) {
 			Err:      fmt.Errorf("at INSERT INTO: need at least one row to insert"),
 		},
 		{
-			Name:     "INSERT with incomplete value section fails #4",
-			SQL:      "INSERT INTO 'a' (b) VALUES",
+			Name:     "INSERT with incomplete value section fails #4",
+			SQL:      "INSERT INTO 'a' (b) VALUES",
+			Expected: Query{},
+			Err:      fmt.Errorf("at INSERT INTO: need at least one row to insert"),
+		},
+		{
+			Name:     "INSERT with incomplete row fails",
+			SQL:      "INSERT INTO 'a' (b) VALUES (",
+			Expected: Query{},
+			Err:      fmt.Errorf("at INSERT INTO: value count doesn't match field count"),
+		},
+		{
+			Name: "INSERT works",
+			SQL:  "INSERT INTO 'a' (b) VALUES ('1')",
+			Expected: Query{
+				Type:      Insert,
+				TableName: "a",
+				Fields:    []string{"b"},
+				Inserts:   [][]ValueExpr{{&Literal{Value: NewString("1")}}},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "INSERT * fails",
+			SQL:      "INSERT INTO 'a' (*) VALUES ('1')",
+			Expected: Query{},
+			Err:      fmt.Errorf("at INSERT INTO: expected at least one field to insert"),
+		},
+		{
+			Name: "INSERT with multiple fields works",
+			SQL:  "INSERT INTO 'a' (b,c,    d) VALUES ('1','2' ,  '3' )",
+			Expected: Query{
+				Type:      Insert,
+				TableName: "a",
+				Fields:    []string{"b", "c", "d"},
+				Inserts:   [][]ValueExpr{{&Literal{Value: NewString("1")}, &Literal{Value: NewString("2")}, &Literal{Value: NewString("3")}}},
+			},
+			Err: nil,
+		},
+		{
+			Name: "INSERT with multiple fields and multiple values works",
+			SQL:  "INSERT INTO 'a' (b,c,    d) VALUES ('1','2' ,  '3' ),('4','5' ,'6' )",
+			Expected: Query{
+				Type:      Insert,
+				TableName: "a",
+				Fields:    []string{"b", "c", "d"},
+				Inserts:   [][]ValueExpr{{&Literal{Value: NewString("1")}, &Literal{Value: NewString("2")}, &Literal{Value: NewString("3")}}, {&Literal{Value: NewString("4")}, &Literal{Value: NewString("5")}, &Literal{Value: NewString("6")}}},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT over several lines with tabs and comments works",
+			SQL:  "SELECT a,\n\tb -- the device\nFROM 'c'\r\nWHERE /* only recent rows */ d > '1'\n",
+			Expected: withWhere(Query{
+				Type:      Select,
+				TableName: "c",
+				Fields:    []string{"a", "b"},
+				Conditions: []Condition{
+					{Operand1: "d", Operand1IsField: true, Operator: Gt, Operand2: "1", Operand2IsField: false, Operand2Value: NewString("1")},
+				},
+			}),
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with unterminated comment fails",
+			SQL:      "SELECT a FROM 'b' /* no end",
+			Expected: Query{Type: Select, TableName: "b", Fields: []string{"a"}},
+			Err:      fmt.Errorf("at SELECT: unexpected '/* no end'"),
+		},
+		{
+			Name: "SELECT with quoted identifiers works",
+			SQL:  `SELECT "Device ID", "temp-c" AS "Temp (C)" FROM "sensor data.csv" WHERE "Device ID" = 'a' AND "temp-c" > "select" ORDER BY "temp-c" DESC`,
+			Expected: withWhere(Query{
+				Type:      Select,
+				TableName: "sensor data.csv",
+				Fields:    []string{"Device ID", "temp-c"},
+				Aliases:   map[string]string{"temp-c": "Temp (C)"},
+				Conditions: []Condition{
+					{Operand1: "Device ID", Operand1IsField: true, Operand1Quoted: true, Operator: Eq, Operand2: "a", Operand2IsField: false, Operand2Value: NewString("a")},
+					{Operand1: "temp-c", Operand1IsField: true, Operand1Quoted: true, Operator: Gt, Operand2: "select", Operand2IsField: true, Operand2Quoted: true},
+				},
+				OrderBy:     []OrderBy{{Field: "temp-c", Desc: true}},
+				QuotedNames: map[string]bool{"Device ID": true, "temp-c": true, "Temp (C)": true, "sensor data.csv": true},
+			}),
+			Err: nil,
+		},
+		{
+			Name: "INSERT with quoted identifiers works",
+			SQL:  `INSERT INTO "a" ("b c", d) VALUES ('1', '2')`,
+			Expected: Query{
+				Type:        Insert,
+				TableName:   "a",
+				Fields:      []string{"b c", "d"},
+				Inserts:     [][]ValueExpr{{&Literal{Value: NewString("1")}, &Literal{Value: NewString("2")}}},
+				QuotedNames: map[string]bool{"a": true, "b c": true},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with empty quoted identifier fails",
+			SQL:      `SELECT "" FROM 'a'`,
+			Expected: Query{Type: Select},
+			Err:      fmt.Errorf("at SELECT: expected field to SELECT"),
+		},
+		{
+			Name:     "SELECT with unterminated quoted identifier fails",
+			SQL:      `SELECT "a FROM 'b'`,
+			Expected: Query{Type: Select},
+			Err:      fmt.Errorf("at SELECT: expected field to SELECT"),
+		},
+		{
+			Name: "SELECT with unquoted literals works",
+			SQL:  "SELECT a FROM 'b' WHERE a > 30 AND b = -1.5e2 AND c != TRUE AND d IN (1, 'x', false, NULL)",
+			Expected: withWhere(Query{
+				Type:      Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Conditions: []Condition{
+					{Operand1: "a", Operand1IsField: true, Operator: Gt, Operand2: "30", Operand2Value: NewInt(30)},
+					{Operand1: "b", Operand1IsField: true, Operator: Eq, Operand2: "-1.5e2", Operand2Value: NewFloat(-150)},
+					{Operand1: "c", Operand1IsField: true, Operator: Ne, Operand2: "TRUE", Operand2Value: NewBool(true)},
+					{Operand1: "d", Operand1IsField: true, Operator: In, InValues: []Value{NewInt(1), NewString("x"), NewBool(false), {}}},
+				},
+			}),
+			Err: nil,
+		},
+		{
+			Name: "INSERT with unquoted literals works",
+			SQL:  "INSERT INTO 'a' (b, c, d, e) VALUES (1, 2.5, TRUE, NULL)",
+			Expected: Query{
+				Type:      Insert,
+				TableName: "a",
+				Fields:    []string{"b", "c", "d", "e"},
+				Inserts:   [][]ValueExpr{{&Literal{Value: NewInt(1)}, &Literal{Value: NewFloat(2.5)}, &Literal{Value: NewBool(true)}, &Literal{}}},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with IS NULL and IS NOT NULL works",
+			SQL:  "SELECT a FROM 'b' WHERE a IS NULL AND b is not null",
+			Expected: withWhere(Query{
+				Type:      Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Conditions: []Condition{
+					{Operand1: "a", Operand1IsField: true, Operator: IsNull},
+					{Operand1: "b", Operand1IsField: true, Operator: IsNotNull},
+				},
+			}),
+			Err: nil,
+		},
+		{
+			Name: "SELECT with BETWEEN and NOT BETWEEN works",
+			SQL:  "SELECT a FROM 'b' WHERE ts BETWEEN 10 AND 20 AND name NOT BETWEEN 'a' AND 'c' OR d = 1",
+			Expected: Query{
+				Type:      Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Where: &OrExpr{
+					Left: &AndExpr{
+						Left: Condition{Operand1: "ts", Operand1IsField: true, Operator: Between,
+							Operand2: "10", Operand2Value: NewInt(10), Operand3: "20", Operand3Value: NewInt(20)},
+						Right: Condition{Operand1: "name", Operand1IsField: true, Operator: NotBetween,
+							Operand2: "a", Operand2Value: NewString("a"), Operand3: "c", Operand3Value: NewString("c")},
+					},
+					Right: Condition{Operand1: "d", Operand1IsField: true, Operator: Eq, Operand2: "1", Operand2Value: NewInt(1)},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with a literal on the left works",
+			SQL:  "SELECT a FROM 'b' WHERE 5 < a AND 'x' = b.c AND d >= e",
+			Expected: withWhere(Query{
+				Type:      Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Conditions: []Condition{
+					{Operand1: "5", Operand1Value: NewInt(5), Operator: Lt, Operand2: "a", Operand2IsField: true},
+					{Operand1: "x", Operand1Value: NewString("x"), Operator: Eq, Operand2: "b.c", Operand2IsField: true},
+					{Operand1: "d", Operand1IsField: true, Operator: Gte, Operand2: "e", Operand2IsField: true},
+				},
+			}),
+			Err: nil,
+		},
+		{
+			Name: "SELECT with BETWEEN bounds of fields and expressions works",
+			SQL:  "SELECT a FROM 'b' WHERE ts BETWEEN start - 1 AND stop",
+			Expected: withWhere(Query{
+				Type:      Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Conditions: []Condition{
+					{Operand1: "ts", Operand1IsField: true, Operator: Between,
+						Operand2: "start - 1", Operand2Expr: &BinaryExpr{Operator: Subtract, Left: &FieldRef{Name: "start"}, Right: &Literal{Value: NewInt(1)}},
+						Operand3: "stop", Operand3IsField: true},
+				},
+			}),
+			Err: nil,
+		},
+		{
+			Name: "SELECT with literals on the left spelled like NOT or a parenthesis works",
+			SQL:  "SELECT a FROM 'b' WHERE 'not' = c AND '(' = d OR \"not\" = 1",
+			Expected: Query{
+				Type:      Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Where: &OrExpr{
+					Left: &AndExpr{
+						Left:  Condition{Operand1: "not", Operand1Value: NewString("not"), Operator: Eq, Operand2: "c", Operand2IsField: true},
+						Right: Condition{Operand1: "(", Operand1Value: NewString("("), Operator: Eq, Operand2: "d", Operand2IsField: true},
+					},
+					Right: Condition{Operand1: "not", Operand1IsField: true, Operand1Quoted: true, Operator: Eq, Operand2: "1", Operand2Value: NewInt(1)},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with BETWEEN and a quoted AND fails",
+			SQL:      "SELECT a FROM 'b' WHERE ts BETWEEN 1 'AND' 2",
+			Expected: Query{},
+			Err:      fmt.Errorf("at WHERE BETWEEN: expected AND"),
+		},
+		{
+			Name:     "SELECT with IN and a quoted comma fails",
+			SQL:      "SELECT a FROM 'b' WHERE c IN ('x' ',' 'y')",
+			Expected: Query{},
+			Err:      fmt.Errorf("at WHERE IN: expected comma or closing parenthesis"),
+		},
+		{
+			Name:     "SELECT with IN and a quoted parenthesis fails",
+			SQL:      "SELECT a FROM 'b' WHERE c IN ('x' ')'",
+			Expected: Query{},
+			Err:      fmt.Errorf("at WHERE IN: expected comma or closing parenthesis"),
+		},
+		{
+			Name:     "SELECT with BETWEEN without AND fails",
+			SQL:      "SELECT a FROM 'b' WHERE ts BETWEEN 10 OR 20",
+			Expected: Query{Type: Select, TableName: "b", Fields: []string{"a"}},
+			Err:      fmt.Errorf("at WHERE BETWEEN: expected AND"),
+		},
+		{
+			Name:     "SELECT with IS without NULL fails",
+			SQL:      "SELECT a FROM 'b' WHERE a IS 'c'",
+			Expected: Query{Type: Select, TableName: "b", Fields: []string{"a"}},
+			Err:      fmt.Errorf("at WHERE: expected NULL after IS"),
+		},
+		{
+			Name: "SELECT with literals as fields works",
+			SQL:  "SELECT 1, null AS nothing, 'x' FROM 'a'",
+			Expected: Query{
+				Type:      Select,
+				TableName: "a",
+				Fields:    []string{"1", "NULL", "'x'"},
+				FieldExprs: map[string]ValueExpr{
+					"1":    &Literal{Value: NewInt(1)},
+					"NULL": &Literal{Value: Value{}},
+					"'x'":  &Literal{Value: NewString("x")},
+				},
+				Aliases: map[string]string{"NULL": "nothing"},
+			},
+			Err: nil,
+		},
+		{
+			Name: "CREATE TABLE",
+			SQL:  "CREATE TABLE test (name string, age number, gender bool)",
+			Expected: Query{
+				Type:      Create,
+				TableName: "test",
+				Columns: []Column{
+					{Name: "name", Type: "string"},
+					{Name: "age", Type: "number"},
+					{Name: "gender", Type: "bool"},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "CREATE TABLE with type parameters",
+			SQL:  "CREATE TABLE test (id INT, name VARCHAR(255), price DECIMAL(10, 2), state ENUM('on', 'off'))",
+			Expected: Query{
+				Type:      Create,
+				TableName: "test",
+				Columns: []Column{
+					{Name: "id", Type: "INT"},
+					{Name: "name", Type: "VARCHAR", TypeParams: []string{"255"}},
+					{Name: "price", Type: "DECIMAL", TypeParams: []string{"10", "2"}},
+					{Name: "state", Type: "ENUM", TypeParams: []string{"'on'", "'off'"}},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "CREATE TABLE with constraints",
+			SQL:  "CREATE TABLE IF NOT EXISTS readings (id INT PRIMARY KEY NOT NULL DEFAULT 0, device VARCHAR(64) UNIQUE REFERENCES devices (id), temp FLOAT CHECK (temp > -50 AND temp < 150), ts INT, CONSTRAINT pk PRIMARY KEY (device, ts), UNIQUE (ts), CHECK (ts >= 0), FOREIGN KEY (device, ts) REFERENCES events (device, ts))",
+			Expected: Query{
+				Type:        Create,
+				TableName:   "readings",
+				IfNotExists: true,
+				Columns: []Column{
+					{Name: "id", Type: "INT", PrimaryKey: true, NotNull: true, Default: &Literal{Value: NewInt(0)}},
+					{Name: "device", Type: "VARCHAR", TypeParams: []string{"64"}, Unique: true, References: &Reference{Table: "devices", Columns: []string{"id"}}},
+					{Name: "temp", Type: "FLOAT", Check: &AndExpr{
+						Left:  Condition{Operand1: "temp", Operand1IsField: true, Operator: Gt, Operand2: "-50", Operand2Value: NewInt(-50)},
+						Right: Condition{Operand1: "temp", Operand1IsField: true, Operator: Lt, Operand2: "150", Operand2Value: NewInt(150)},
+					}},
+					{Name: "ts", Type: "INT"},
+				},
+				Constraints: []TableConstraint{
+					{Type: PrimaryKeyConstraint, Name: "pk", Columns: []string{"device", "ts"}},
+					{Type: UniqueConstraint, Columns: []string{"ts"}},
+					{Type: CheckConstraint, Check: Condition{Operand1: "ts", Operand1IsField: true, Operator: Gte, Operand2: "0", Operand2Value: NewInt(0)}},
+					{Type: ForeignKeyConstraint, Columns: []string{"device", "ts"}, References: &Reference{Table: "events", Columns: []string{"device", "ts"}}},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "CREATE TABLE with NOT without NULL fails",
+			SQL:      "CREATE TABLE test (id INT NOT 0)",
+			Expected: Query{Type: Create, TableName: "test"},
+			Err:      fmt.Errorf("syntax error, expect NULL after NOT"),
+		},
+		{
+			Name: "CREATE TABLE with DEFAULT expressions",
+			SQL:  "CREATE TABLE test (ts INT DEFAULT NOW() NOT NULL, level INT DEFAULT -(2 * 3), name VARCHAR(8) DEFAULT UPPER('x'))",
+			Expected: Query{
+				Type:      Create,
+				TableName: "test",
+				Columns: []Column{
+					{Name: "ts", Type: "INT", NotNull: true, Default: &FuncCall{Name: "NOW"}},
+					{Name: "level", Type: "INT", Default: &UnaryExpr{Operator: Subtract, Expr: &BinaryExpr{Operator: Multiply, Left: &Literal{Value: NewInt(2)}, Right: &Literal{Value: NewInt(3)}}}},
+					{Name: "name", Type: "VARCHAR", TypeParams: []string{"8"}, Default: &FuncCall{Name: "UPPER", Args: []ValueExpr{&Literal{Value: NewString("x")}}}},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "CREATE TABLE with DEFAULT without value fails",
+			SQL:      "CREATE TABLE test (id INT DEFAULT, name string)",
+			Expected: Query{Type: Create, TableName: "test"},
+			Err:      fmt.Errorf("syntax error, expect DEFAULT value"),
+		},
+		{
+			Name:     "CREATE TABLE with a column in DEFAULT fails",
+			SQL:      "CREATE TABLE test (id INT, n INT DEFAULT id + 1)",
+			Expected: Query{Type: Create, TableName: "test", Columns: []Column{{Name: "id", Type: "INT"}}},
+			Err:      fmt.Errorf("at DEFAULT: column id can't be referenced in a DEFAULT value"),
+		},
+		{
+			Name:     "CREATE TABLE with a column after a table constraint fails",
+			SQL:      "CREATE TABLE test (id INT, PRIMARY KEY (id), name string)",
+			Expected: Query{Type: Create, TableName: "test", Columns: []Column{{Name: "id", Type: "INT"}}, Constraints: []TableConstraint{{Type: PrimaryKeyConstraint, Columns: []string{"id"}}}},
+			Err:      fmt.Errorf("syntax error, expect table constraint after the first one"),
+		},
+		{
+			Name:     "CREATE TABLE with FOREIGN KEY without REFERENCES fails",
+			SQL:      "CREATE TABLE test (id INT, FOREIGN KEY (id))",
+			Expected: Query{Type: Create, TableName: "test", Columns: []Column{{Name: "id", Type: "INT"}}},
+			Err:      fmt.Errorf("syntax error, expect REFERENCES"),
+		},
+		{
+			Name:     "DROP TABLE works",
+			SQL:      "DROP TABLE IF EXISTS readings",
+			Expected: Query{Type: Drop, TableName: "readings", IfExists: true},
+			Err:      nil,
+		},
+		{
+			Name:     "TRUNCATE works",
+			SQL:      "TRUNCATE TABLE readings",
+			Expected: Query{Type: Truncate, TableName: "readings"},
+			Err:      nil,
+		},
+		{
+			Name: "ALTER TABLE ADD COLUMN works",
+			SQL:  "ALTER TABLE readings ADD COLUMN unit VARCHAR(8) NOT NULL DEFAULT 'C'",
+			Expected: Query{Type: Alter, TableName: "readings", Alter: &AlterTable{
+				Action: AddColumn,
+				Column: Column{Name: "unit", Type: "VARCHAR", TypeParams: []string{"8"}, NotNull: true, Default: &Literal{Value: NewString("C")}},
+			}},
+			Err: nil,
+		},
+		{
+			Name:     "ALTER TABLE RENAME COLUMN works",
+			SQL:      "ALTER TABLE readings RENAME temp TO temperature",
+			Expected: Query{Type: Alter, TableName: "readings", Alter: &AlterTable{Action: RenameColumn, Column: Column{Name: "temp"}, NewName: "temperature"}},
+			Err:      nil,
+		},
+		{
+			Name:     "ALTER TABLE without action fails",
+			SQL:      "ALTER TABLE readings",
+			Expected: Query{Type: Alter, TableName: "readings"},
+			Err:      fmt.Errorf("at ALTER TABLE: expected action"),
+		},
+		{
+			Name:     "ALTER TABLE with unknown action fails",
+			SQL:      "ALTER TABLE readings MODIFY temp INT",
+			Expected: Query{Type: Alter, TableName: "readings"},
+			Err:      fmt.Errorf("at ALTER TABLE: unknown action"),
+		},
+		{
+			Name:     "DROP TABLE with a trailing token fails",
+			SQL:      "DROP TABLE readings CASCADE",
+			Expected: Query{Type: Drop, TableName: "readings"},
+			Err:      fmt.Errorf("unexpected 'CASCADE'"),
+		},
+		{
+			Name:     "CREATE TABLE with a duplicate column fails",
+			SQL:      "CREATE TABLE test (name string, age number, name bool)",
+			Expected: Query{Type: Create, TableName: "test", Columns: []Column{{Name: "name", Type: "string"}, {Name: "age", Type: "number"}}},
+			Err:      fmt.Errorf("at CREATE TABLE: duplicate column name"),
+		},
+		{
+			Name:     "CREATE TABLE with empty type parameters fails",
+			SQL:      "CREATE TABLE test (name VARCHAR())",
+			Expected: Query{Type: Create, TableName: "test"},
+			Err:      fmt.Errorf("syntax error, expect type parameter"),
+		},
+		{
+			Name:     "CREATE TABLE with columns after the closing parenthesis fails",
+			SQL:      "CREATE TABLE t (id INT) name TEXT)",
+			Expected: Query{},
+			Err:      fmt.Errorf("unexpected 'name'"),
+		},
+		{
+			Name:     "CREATE TABLE without closing parenthesis fails",
+			SQL:      "CREATE TABLE t (id INT,",
+			Expected: Query{},
+			Err:      fmt.Errorf("at CREATE TABLE: expected ')'"),
+		},
+		{
+			Name:     "CREATE TABLE without columns fails",
+			SQL:      "CREATE TABLE t (",
+			Expected: Query{},
+			Err:      fmt.Errorf("at CREATE TABLE: expected ')'"),
+		},
+		{
+			Name:     "CREATE TABLE with only table constraints fails",
+			SQL:      "CREATE TABLE t (CHECK (a > 1))",
 			Expected: Query{},
-			Err:      fmt.Errorf("at INSERT INTO: need at least one row to insert"),
+			Err:      fmt.Errorf("at CREATE TABLE: expected at least one column"),
 		},
 		{
-			Name:     "INSERT with incomplete row fails",
-			SQL:      "INSERT INTO 'a' (b) VALUES (",
+			Name:     "CREATE TABLE with a comma as column type fails",
+			SQL:      "CREATE TABLE t (a ,)",
 			Expected: Query{},
-			Err:      fmt.Errorf("at INSERT INTO: value count doesn't match field count"),
+			Err:      fmt.Errorf("syntax error, expect filed type"),
 		},
 		{
-			Name: "INSERT works",
-			SQL:  "INSERT INTO 'a' (b) VALUES ('1')",
-			Expected: Query{
-				Type:      Insert,
-				TableName: "a",
-				Fields:    []string{"b"},
-				Inserts:   [][]string{{"1"}},
-			},
-			Err: nil,
+			Name:     "CREATE TABLE with a parenthesis as column name fails",
+			SQL:      "CREATE TABLE t (( INT)",
+			Expected: Query{},
+			Err:      fmt.Errorf("at CREATE TABLE: expected column name"),
 		},
 		{
-			Name:     "INSERT * fails",
-			SQL:      "INSERT INTO 'a' (*) VALUES ('1')",
+			Name:     "CREATE TABLE with a number as column name fails",
+			SQL:      "CREATE TABLE t (1 INT)",
 			Expected: Query{},
-			Err:      fmt.Errorf("at INSERT INTO: expected at least one field to insert"),
+			Err:      fmt.Errorf("at CREATE TABLE: expected column name"),
 		},
 		{
-			Name: "INSERT with multiple fields works",
-			SQL:  "INSERT INTO 'a' (b,c,    d) VALUES ('1','2' ,  '3' )",
-			Expected: Query{
-				Type:      Insert,
-				TableName: "a",
-				Fields:    []string{"b", "c", "d"},
-				Inserts:   [][]string{{"1", "2", "3"}},
-			},
-			Err: nil,
+			Name:     "CREATE TABLE with a reserved word as column name fails",
+			SQL:      "CREATE TABLE t (select INT)",
+			Expected: Query{},
+			Err:      fmt.Errorf("at CREATE TABLE: expected column name"),
 		},
 		{
-			Name: "INSERT with multiple fields and multiple values works",
-			SQL:  "INSERT INTO 'a' (b,c,    d) VALUES ('1','2' ,  '3' ),('4','5' ,'6' )",
-			Expected: Query{
-				Type:      Insert,
-				TableName: "a",
-				Fields:    []string{"b", "c", "d"},
-				Inserts:   [][]string{{"1", "2", "3"}, {"4", "5", "6"}},
-			},
-			Err: nil,
+			Name:     "CREATE TABLE with empty parentheses fails",
+			SQL:      "CREATE TABLE t ()",
+			Expected: Query{},
+			Err:      fmt.Errorf("at CREATE TABLE: expected column name"),
 		},
 		{
-			Name: "CREATE TABLE",
-			SQL:  "CREATE TABLE test (name string, age number, gender bool)",
-			Expected: Query{
-				Type:      Create,
-				TableName: "test",
-				CreateFields: map[string]string{
-					"name":   "string",
-					"age":    "number",
-					"gender": "bool",
-				},
-			},
-			Err: nil,
+			Name:     "CREATE TABLE without table name fails",
+			SQL:      "CREATE TABLE",
+			Expected: Query{},
+			Err:      fmt.Errorf("table name cannot be empty"),
 		},
 	}
 
@@ -476,6 +1373,18 @@ func TestSQL(t *testing.T) {
 	createReadme(output)
 }
 
+// withWhere sets the WHERE tree expected for q.Conditions, i.e. a left-deep AND chain
+func withWhere(q Query) Query {
+	for i, cond := range q.Conditions {
+		if i == 0 {
+			q.Where = cond
+			continue
+		}
+		q.Where = &AndExpr{Left: q.Where, Right: cond}
+	}
+	return q
+}
+
 func createReadme(out output) {
 	content, err := os.ReadFile("README.template")
 	if err != nil {
@@ -502,7 +1411,7 @@ func TestParseLikeAndInOperators(t *testing.T) {
 		{
 			name: "SELECT with LIKE operator",
 			sql:  "SELECT name FROM users WHERE name LIKE 'John%'",
-			expected: Query{
+			expected: withWhere(Query{
 				Type:      Select,
 				TableName: "users",
 				Fields:    []string{"name"},
@@ -513,15 +1422,16 @@ func TestParseLikeAndInOperators(t *testing.T) {
 						Operator:        Like,
 						Operand2:        "John%",
 						Operand2IsField: false,
+						Operand2Value:   NewString("John%"),
 					},
 				},
-			},
+			}),
 			hasError: false,
 		},
 		{
 			name: "SELECT with NOT LIKE operator",
 			sql:  "SELECT * FROM products WHERE name NOT LIKE '%test%'",
-			expected: Query{
+			expected: withWhere(Query{
 				Type:      Select,
 				TableName: "products",
 				Fields:    []string{"*"},
@@ -532,15 +1442,16 @@ func TestParseLikeAndInOperators(t *testing.T) {
 						Operator:        NotLike,
 						Operand2:        "%test%",
 						Operand2IsField: false,
+						Operand2Value:   NewString("%test%"),
 					},
 				},
-			},
+			}),
 			hasError: false,
 		},
 		{
 			name: "DELETE with LIKE operator",
 			sql:  "DELETE FROM logs WHERE message LIKE 'Error:%'",
-			expected: Query{
+			expected: withWhere(Query{
 				Type:      Delete,
 				TableName: "logs",
 				Conditions: []Condition{
@@ -550,18 +1461,19 @@ func TestParseLikeAndInOperators(t *testing.T) {
 						Operator:        Like,
 						Operand2:        "Error:%",
 						Operand2IsField: false,
+						Operand2Value:   NewString("Error:%"),
 					},
 				},
-			},
+			}),
 			hasError: false,
 		},
 		{
 			name: "UPDATE with LIKE operator",
 			sql:  "UPDATE products SET price = '99' WHERE name LIKE 'Pro%'",
-			expected: Query{
-				Type:      Update,
-				TableName: "products",
-				Updates:   map[string]string{"price": "99"},
+			expected: withWhere(Query{
+				Type:        Update,
+				TableName:   "products",
+				Assignments: []Assignment{{Field: "price", Value: &Literal{Value: NewString("99")}}},
 				Conditions: []Condition{
 					{
 						Operand1:        "name",
@@ -569,15 +1481,16 @@ func TestParseLikeAndInOperators(t *testing.T) {
 						Operator:        Like,
 						Operand2:        "Pro%",
 						Operand2IsField: false,
+						Operand2Value:   NewString("Pro%"),
 					},
 				},
-			},
+			}),
 			hasError: false,
 		},
 		{
 			name: "SELECT with IN operator",
 			sql:  "SELECT name FROM users WHERE id IN ('1', '2', '3')",
-			expected: Query{
+			expected: withWhere(Query{
 				Type:      Select,
 				TableName: "users",
 				Fields:    []string{"name"},
@@ -586,16 +1499,16 @@ func TestParseLikeAndInOperators(t *testing.T) {
 						Operand1:        "id",
 						Operand1IsField: true,
 						Operator:        In,
-						InValues:        []string{"1", "2", "3"},
+						InValues:        []Value{NewString("1"), NewString("2"), NewString("3")},
 					},
 				},
-			},
+			}),
 			hasError: false,
 		},
 		{
 			name: "SELECT with NOT IN operator",
 			sql:  "SELECT * FROM products WHERE status NOT IN ('sold', 'discontinued')",
-			expected: Query{
+			expected: withWhere(Query{
 				Type:      Select,
 				TableName: "products",
 				Fields:    []string{"*"},
@@ -604,16 +1517,16 @@ func TestParseLikeAndInOperators(t *testing.T) {
 						Operand1:        "status",
 						Operand1IsField: true,
 						Operator:        NotIn,
-						InValues:        []string{"sold", "discontinued"},
+						InValues:        []Value{NewString("sold"), NewString("discontinued")},
 					},
 				},
-			},
+			}),
 			hasError: false,
 		},
 		{
 			name: "DELETE with IN operator",
 			sql:  "DELETE FROM logs WHERE level IN ('INFO', 'DEBUG')",
-			expected: Query{
+			expected: withWhere(Query{
 				Type:      Delete,
 				TableName: "logs",
 				Conditions: []Condition{
@@ -621,28 +1534,28 @@ func TestParseLikeAndInOperators(t *testing.T) {
 						Operand1:        "level",
 						Operand1IsField: true,
 						Operator:        In,
-						InValues:        []string{"INFO", "DEBUG"},
+						InValues:        []Value{NewString("INFO"), NewString("DEBUG")},
 					},
 				},
-			},
+			}),
 			hasError: false,
 		},
 		{
 			name: "UPDATE with IN operator",
 			sql:  "UPDATE users SET active = 'false' WHERE id IN ('10', '20')",
-			expected: Query{
-				Type:      Update,
-				TableName: "users",
-				Updates:   map[string]string{"active": "false"},
+			expected: withWhere(Query{
+				Type:        Update,
+				TableName:   "users",
+				Assignments: []Assignment{{Field: "active", Value: &Literal{Value: NewString("false")}}},
 				Conditions: []Condition{
 					{
 						Operand1:        "id",
 						Operand1IsField: true,
 						Operator:        In,
-						InValues:        []string{"10", "20"},
+						InValues:        []Value{NewString("10"), NewString("20")},
 					},
 				},
-			},
+			}),
 			hasError: false,
 		},
 		{
@@ -741,6 +1654,36 @@ func TestFilter(t *testing.T) {
 			expected:    map[string]map[string]any{"2": data["2"], "5": data["5"]},
 			expectedErr: "",
 		},
+		{
+			name:        "SELECT with a number compares numeric strings numerically",
+			sql:         "SELECT * FROM users WHERE age >= 30 AND age < 4e1",
+			expected:    map[string]map[string]any{"1": data["1"], "3": data["3"]},
+			expectedErr: "",
+		},
+		{
+			name:        "SELECT with a number doesn't match non-numeric strings",
+			sql:         "SELECT * FROM users WHERE name > 0 OR name = 0",
+			expected:    map[string]map[string]any{},
+			expectedErr: "",
+		},
+		{
+			name:        "SELECT with BETWEEN includes both bounds",
+			sql:         "SELECT * FROM users WHERE age BETWEEN 28 AND 35 AND status = 'active'",
+			expected:    map[string]map[string]any{"1": data["1"], "3": data["3"]},
+			expectedErr: "",
+		},
+		{
+			name:        "SELECT with BETWEEN computed bounds",
+			sql:         "SELECT * FROM users WHERE age BETWEEN id * 10 AND id * 10 + 5",
+			expected:    map[string]map[string]any{"2": data["2"], "3": data["3"], "4": data["4"]},
+			expectedErr: "",
+		},
+		{
+			name:        "SELECT with NOT BETWEEN on strings",
+			sql:         "SELECT * FROM users WHERE name NOT BETWEEN 'Jane' AND 'Joh'",
+			expected:    map[string]map[string]any{"1": data["1"], "4": data["4"], "3": data["3"], "5": data["5"]},
+			expectedErr: "",
+		},
 		{
 			name:        "SELECT with '>' operator (string comparison)",
 			sql:         "SELECT * FROM users WHERE age > '30'",
@@ -783,6 +1726,30 @@ func TestFilter(t *testing.T) {
 			expected:    map[string]map[string]any{"2": data["2"], "4": data["4"]},
 			expectedErr: "",
 		},
+		{
+			name:        "SELECT with 'OR' condition",
+			sql:         "SELECT * FROM users WHERE city = 'Chicago' OR name = 'Jane Smith'",
+			expected:    map[string]map[string]any{"2": data["2"], "4": data["4"]},
+			expectedErr: "",
+		},
+		{
+			name:        "SELECT with 'AND' binding tighter than 'OR'",
+			sql:         "SELECT * FROM users WHERE city = 'Chicago' OR status = 'inactive' AND city = 'New York'",
+			expected:    map[string]map[string]any{"4": data["4"], "5": data["5"]},
+			expectedErr: "",
+		},
+		{
+			name:        "SELECT with parentheses overriding precedence",
+			sql:         "SELECT * FROM users WHERE (city = 'Chicago' OR status = 'inactive') AND age > '26'",
+			expected:    map[string]map[string]any{"4": data["4"], "5": data["5"]},
+			expectedErr: "",
+		},
+		{
+			name:        "SELECT with NOT",
+			sql:         "SELECT * FROM users WHERE NOT city = 'New York' AND NOT (status = 'active' AND age > '35')",
+			expected:    map[string]map[string]any{"2": data["2"]},
+			expectedErr: "",
+		},
 		{
 			name:        "SELECT with no matching results",
 			sql:         "SELECT * FROM users WHERE age = '50'",
@@ -847,10 +1814,469 @@ func TestFilter(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := FilterRecursive(tt.sql, data)
-			if err != nil {
-				t.Error(err)
+			result, err := FilterRecursive(tt.sql, data)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestFilterNulls(t *testing.T) {
+	data := map[string]map[string]any{
+		"1": {"id": "1", "temp": 20},
+		"2": {"id": "2", "temp": nil},
+		"3": {"id": "3"},
+		"4": {"id": "4", "temp": 40, "sensor": map[string]any{"name": "s4"}},
+	}
+
+	tests := []struct {
+		name     string
+		sql      string
+		expected []string
+	}{
+		{name: "IS NULL matches nil and missing fields", sql: "SELECT * FROM t WHERE temp IS NULL", expected: []string{"2", "3"}},
+		{name: "IS NOT NULL", sql: "SELECT * FROM t WHERE temp IS NOT NULL", expected: []string{"1", "4"}},
+		{name: "IS NULL on a nested field", sql: "SELECT * FROM t WHERE sensor.name IS NULL", expected: []string{"1", "2", "3"}},
+		{name: "!= is unknown for NULL", sql: "SELECT * FROM t WHERE temp != 20", expected: []string{"4"}},
+		{name: "NOT IN is unknown for NULL", sql: "SELECT * FROM t WHERE temp NOT IN (20)", expected: []string{"4"}},
+		{name: "NOT IN with NULL in the list is never true", sql: "SELECT * FROM t WHERE temp NOT IN (20, NULL)", expected: []string{}},
+		{name: "IN with NULL in the list matches", sql: "SELECT * FROM t WHERE temp IN (20, NULL)", expected: []string{"1"}},
+		{name: "= NULL is unknown", sql: "SELECT * FROM t WHERE temp = NULL OR NOT temp = NULL", expected: []string{}},
+		{name: "NOT of unknown is unknown", sql: "SELECT * FROM t WHERE NOT temp > 30", expected: []string{"1"}},
+		{name: "NOT BETWEEN is unknown for NULL", sql: "SELECT * FROM t WHERE temp NOT BETWEEN 30 AND 50", expected: []string{"1"}},
+		{name: "BETWEEN a NULL bound is unknown", sql: "SELECT * FROM t WHERE id BETWEEN '0' AND sensor.name", expected: []string{"4"}},
+		{name: "unknown OR true is true", sql: "SELECT * FROM t WHERE temp > 30 OR id = '3'", expected: []string{"3", "4"}},
+		{name: "unknown AND false is false", sql: "SELECT * FROM t WHERE NOT (temp > 30 AND id = '1')", expected: []string{"1", "2", "3", "4"}},
+		{name: "unknown AND true is unknown", sql: "SELECT * FROM t WHERE NOT (temp > 30 AND id != '4')", expected: []string{"1", "4"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := FilterRecursive(tt.sql, data)
+			require.NoError(t, err)
+			expected := map[string]map[string]any{}
+			for _, key := range tt.expected {
+				expected[key] = data[key]
+			}
+			require.Equal(t, expected, result)
+		})
+	}
+}
+
+func TestFilterFieldOperands(t *testing.T) {
+	data := map[string]map[string]any{
+		"1": {"min_temp": "9", "max_temp": "10", "limits": map[string]any{"max": 12}},
+		"2": {"min_temp": "15", "max_temp": "10", "limits": map[string]any{"max": 12}},
+		"3": {"min_temp": 20.5, "max_temp": "20.5"},
+		"4": {"min_temp": "cold", "max_temp": "warm"},
+		"5": {"min_temp": "1"},
+	}
+
+	tests := []struct {
+		name     string
+		sql      string
+		expected []string
+	}{
+		{name: "numeric strings compare numerically", sql: "SELECT * FROM t WHERE min_temp < max_temp", expected: []string{"1", "4"}},
+		{name: "numbers equal numeric strings", sql: "SELECT * FROM t WHERE min_temp = max_temp", expected: []string{"3"}},
+		{name: "a missing field is unknown", sql: "SELECT * FROM t WHERE NOT min_temp = max_temp", expected: []string{"1", "2", "4"}},
+		{name: "a dotted path on the right", sql: "SELECT * FROM t WHERE max_temp < limits.max", expected: []string{"1", "2"}},
+		{name: "a literal on the left", sql: "SELECT * FROM t WHERE 10 < min_temp", expected: []string{"2", "3"}},
+		{name: "a quoted literal on the left compares as text", sql: "SELECT * FROM t WHERE '10' < min_temp", expected: []string{"1", "2", "3", "4"}},
+		{name: "literals on both sides", sql: "SELECT * FROM t WHERE 1 = 1 AND 'a' LIKE 'a%' AND min_temp = '1'", expected: []string{"5"}},
+		{name: "arithmetic on numeric strings", sql: "SELECT * FROM t WHERE max_temp - min_temp >= 1", expected: []string{"1"}},
+		{name: "arithmetic on both sides", sql: "SELECT * FROM t WHERE min_temp * 2 > limits.max + 5", expected: []string{"1", "2"}},
+		{name: "arithmetic on text is unknown", sql: "SELECT * FROM t WHERE NOT min_temp + 1 > 0", expected: []string{}},
+		{name: "a division by zero is unknown", sql: "SELECT * FROM t WHERE min_temp / (max_temp - 10) > 0 OR min_temp = '1'", expected: []string{"3", "5"}},
+		{name: "concatenation", sql: "SELECT * FROM t WHERE min_temp || '-' || max_temp LIKE '%-warm'", expected: []string{"4"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := FilterRecursive(tt.sql, data)
+			require.NoError(t, err)
+			expected := map[string]map[string]any{}
+			for _, key := range tt.expected {
+				expected[key] = data[key]
+			}
+			require.Equal(t, expected, result)
+		})
+	}
+}
+
+func TestQueryStringRoundTrip(t *testing.T) {
+	tests := []string{
+		"SELECT a, b AS z FROM b WHERE a = '1' AND c != d",
+		"SELECT a FROM b WHERE a = '1' OR (b > '2' AND NOT c LIKE 'x%')",
+		"SELECT a FROM b WHERE NOT (a IN ('1', '2') OR b NOT IN ('3'))",
+		"DELETE FROM a WHERE b = '1' OR c = '2' AND d = '3'",
+		"SELECT a FROM b WHERE a > '1' ORDER BY a DESC, b LIMIT 5 OFFSET 10",
+		"SELECT device, COUNT(*) AS n, MAX(ts) FROM t GROUP BY device HAVING COUNT(*) > '10' OR MAX(ts) < '5' ORDER BY n DESC",
+		`SELECT "Device ID", COUNT("temp c") AS "n" FROM "my table" WHERE "a b" = "c""d" GROUP BY "Device ID" ORDER BY "n" DESC`,
+		`UPDATE "a" SET "b c" = '1' WHERE "d" = '2'`,
+		`CREATE TABLE "a" ("b c" string)`,
+		"CREATE TABLE a (id INT, name VARCHAR(255), price DECIMAL(10, 2), b string)",
+		"DROP TABLE a",
+		`DROP TABLE IF EXISTS "a b"`,
+		"TRUNCATE TABLE a",
+		"ALTER TABLE a ADD COLUMN b INT NOT NULL DEFAULT 0",
+		"CREATE TABLE a (b INT DEFAULT NOW(), c FLOAT DEFAULT -1.5 * 2, d TEXT DEFAULT LOWER('X') || 'y')",
+		`ALTER TABLE a DROP COLUMN "b c"`,
+		"ALTER TABLE a RENAME COLUMN b TO c",
+		`ALTER TABLE a RENAME TO "b c"`,
+		"ALTER TABLE a ALTER COLUMN b TYPE DECIMAL(10, 2)",
+		"CREATE TABLE IF NOT EXISTS a (id INT PRIMARY KEY NOT NULL UNIQUE DEFAULT 'x' CHECK (id > 0 OR id IS NULL) REFERENCES b (c), d INT, CONSTRAINT \"my pk\" PRIMARY KEY (id, d), UNIQUE (d), CHECK (d != 1), FOREIGN KEY (d) REFERENCES \"e f\" (g, \"h\"))",
+		"SELECT a FROM b WHERE a > -30 AND b = 1.5 AND c != FALSE OR d IN (1, 'x', NULL) OR e < 2.0",
+		"INSERT INTO a (b, c) VALUES (1, 'x'), (TRUE, NULL)",
+		"UPDATE a SET b = 2.5 WHERE c = 'd'",
+		"UPDATE a SET b = (c + 1) * 2, d = NOW(), e = f - (g - h) WHERE i = 1",
+		"SELECT a FROM b WHERE a IS NULL OR NOT (b IS NOT NULL AND c = 1)",
+		"SELECT a FROM b WHERE a BETWEEN 1 AND 2.5 AND b NOT BETWEEN 'x' AND 'y'",
+		`SELECT a FROM b WHERE a BETWEEN c - 1 AND "d e" OR f NOT BETWEEN UPPER(g) AND CASE WHEN h > 1 THEN 'z' END`,
+		"SELECT a FROM b WHERE 5 < a AND 'x' = b.c OR d >= e",
+		"SELECT a, COUNT(*) FROM b GROUP BY a HAVING 10 < COUNT(*) AND MAX(c) > MIN(c)",
+		"SELECT a * (b + c) AS d, -e, f || 'x' || g FROM h WHERE (i - 1) / 2 >= j % 3 AND -(k - l) < -1",
+		"SELECT a, SUM(b) * 2 AS c FROM d GROUP BY a HAVING SUM(b) / COUNT(*) > 1",
+		"INSERT INTO a (b) VALUES (-1)",
+		"SELECT UPPER(a), SUBSTR(b, 2, 3) AS c, NOW() FROM d WHERE LENGTH(e) > 3 AND COALESCE(f, g, 'x') != ABS(h - 1)",
+		"SELECT a, ROUND(AVG(b), 2) AS c FROM d GROUP BY a HAVING ROUND(AVG(b)) > 1",
+		"SELECT CASE WHEN a > 1 THEN 'hi' ELSE 'lo' END AS b, CASE c WHEN 1 THEN 'x' WHEN 2 THEN 'y' END FROM d WHERE CASE WHEN e IS NULL THEN 0 ELSE e END > 1",
+		"UPDATE a SET b = CASE WHEN c = 1 OR (d > 2 AND NOT e LIKE 'x%') THEN -1 ELSE CASE f WHEN 'g' THEN 0 END END + 1 WHERE h = 1",
+		"SELECT CAST(a AS INT), -CAST(b AS FLOAT) AS c, CAST(CAST(d + 1 AS TEXT) AS BOOL) FROM e WHERE CAST(f AS TIMESTAMP) > NOW() AND CAST(g AS INT) IN (1, 2)",
+		"SELECT a FROM 'logs/data.csv' WHERE b = 1",
+		"DELETE FROM 'my table' WHERE a = 1",
+		"INSERT INTO 'logs/data.csv' (a) VALUES (1)",
+		"UPDATE 'my table' SET a = 1 WHERE b = 2",
+		"CREATE TABLE 'my table' (a INT REFERENCES 'other table' (b))",
+		"DROP TABLE IF EXISTS 'logs/data.csv'",
+		"TRUNCATE TABLE 'my table'",
+		"ALTER TABLE 'my table' RENAME TO 'logs/old-data.csv'",
+		`SELECT a FROM "it's"`,
+		`SELECT end, CASE WHEN "end" > 1 THEN "else" ELSE 0 END AS then FROM a WHERE when = 1`,
+	}
+
+	for _, sql := range tests {
+		t.Run(sql, func(t *testing.T) {
+			q, err := Parse(sql)
+			require.NoError(t, err)
+			require.Equal(t, sql, q.String())
+			reparsed, err := Parse(q.String())
+			require.NoError(t, err)
+			require.Equal(t, q, reparsed)
+		})
+	}
+}
+
+func TestFilterOrdered(t *testing.T) {
+	data := map[string]map[string]any{
+		"1": {"id": "1", "name": "John Doe", "age": "30", "city": "New York"},
+		"2": {"id": "2", "name": "Jane Smith", "age": "25", "city": "Los Angeles"},
+		"3": {"id": "3", "name": "Peter Jones", "age": "35", "city": "New York"},
+		"4": {"id": "4", "name": "David Lee", "age": "100", "city": "Chicago"},
+		"5": {"id": "5", "name": "John Smith", "age": "28"},
+	}
+
+	tests := []struct {
+		name        string
+		sql         string
+		expected    []map[string]any
+		expectedErr string
+	}{
+		{
+			name:     "no ORDER BY keeps key order",
+			sql:      "SELECT * FROM users WHERE age > 28",
+			expected: []map[string]any{data["1"], data["3"], data["4"]},
+		},
+		{
+			name:     "ORDER BY compares numbers numerically",
+			sql:      "SELECT * FROM users ORDER BY age",
+			expected: []map[string]any{data["2"], data["5"], data["1"], data["3"], data["4"]},
+		},
+		{
+			name:     "ORDER BY DESC",
+			sql:      "SELECT * FROM users ORDER BY age DESC",
+			expected: []map[string]any{data["4"], data["3"], data["1"], data["5"], data["2"]},
+		},
+		{
+			name:     "ORDER BY several keys with missing values first",
+			sql:      "SELECT * FROM users ORDER BY city, name DESC",
+			expected: []map[string]any{data["5"], data["4"], data["2"], data["3"], data["1"]},
+		},
+		{
+			name:     "ORDER BY aliases of fields and computed fields",
+			sql:      "SELECT name AS n, age * 2 AS double FROM users ORDER BY double DESC, n",
+			expected: []map[string]any{data["4"], data["3"], data["1"], data["5"], data["2"]},
+		},
+		{
+			name:     "ORDER BY an alias",
+			sql:      "SELECT name AS n FROM users ORDER BY n DESC",
+			expected: []map[string]any{data["3"], data["5"], data["1"], data["2"], data["4"]},
+		},
+		{
+			name:     "LIMIT and OFFSET",
+			sql:      "SELECT * FROM users ORDER BY age LIMIT 2 OFFSET 1",
+			expected: []map[string]any{data["5"], data["1"]},
+		},
+		{
+			name:     "OFFSET past the end",
+			sql:      "SELECT * FROM users OFFSET 10",
+			expected: []map[string]any{},
+		},
+		{
+			name:     "LIMIT 0",
+			sql:      "SELECT * FROM users LIMIT 0",
+			expected: []map[string]any{},
+		},
+		{
+			name:        "non SELECT query fails",
+			sql:         "DELETE FROM users WHERE id = '1'",
+			expectedErr: "only SELECT queries can be filtered",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := FilterOrdered(tt.sql, data)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestFilterProjected(t *testing.T) {
+	data := map[string]map[string]any{
+		"1": {"id": "1", "name": "John Doe", "age": "30",
+			"address": map[string]any{"street": "123 Main St", "zip": "10001"}},
+		"2": {"id": "2", "name": "Jane Smith", "age": "25",
+			"address": map[string]any{"street": "456 Oak Ave", "zip": "90001"}},
+		"3": {"id": "3", "name": "Peter Jones", "age": "35"},
+	}
+
+	tests := []struct {
+		name        string
+		sql         string
+		expected    []map[string]any
+		expectedErr string
+	}{
+		{
+			name:     "* keeps whole rows",
+			sql:      "SELECT * FROM users WHERE age > '26'",
+			expected: []map[string]any{data["1"], data["3"]},
+		},
+		{
+			name: "only SELECTed fields are kept",
+			sql:  "SELECT id, name FROM users WHERE age < '35'",
+			expected: []map[string]any{
+				{"id": "1", "name": "John Doe"},
+				{"id": "2", "name": "Jane Smith"},
+			},
+		},
+		{
+			name: "aliases rename fields and can be ordered by",
+			sql:  "SELECT name AS z, age FROM users ORDER BY z",
+			expected: []map[string]any{
+				{"z": "Jane Smith", "age": "25"},
+				{"z": "John Doe", "age": "30"},
+				{"z": "Peter Jones", "age": "35"},
+			},
+		},
+		{
+			name: "dotted paths resolve into nested maps and missing fields are nil",
+			sql:  "SELECT id, address.zip AS zip FROM users",
+			expected: []map[string]any{
+				{"id": "1", "zip": "10001"},
+				{"id": "2", "zip": "90001"},
+				{"id": "3", "zip": nil},
+			},
+		},
+		{
+			name: "a field together with *",
+			sql:  "SELECT age AS years, * FROM users WHERE id = '3'",
+			expected: []map[string]any{
+				{"years": "35", "id": "3", "name": "Peter Jones", "age": "35"},
+			},
+		},
+		{
+			name: "aggregate queries are grouped",
+			sql:  "SELECT COUNT(*) AS n FROM users",
+			expected: []map[string]any{
+				{"n": 3},
+			},
+		},
+		{
+			name: "computed fields can be ordered by",
+			sql:  "SELECT id, age * 12 + 1 AS months, name || ', ' || id FROM users WHERE age % 2 = 1 ORDER BY months DESC",
+			expected: []map[string]any{
+				{"id": "3", "months": int64(421), "name || ', ' || id": "Peter Jones, 3"},
+				{"id": "2", "months": int64(301), "name || ', ' || id": "Jane Smith, 2"},
+			},
+		},
+		{
+			name: "computed fields of missing fields and divisions by zero are nil",
+			sql:  "SELECT id, address.zip / 1000 AS zone, age / (id - id) AS never FROM users WHERE id != '2'",
+			expected: []map[string]any{
+				{"id": "1", "zone": int64(10), "never": nil},
+				{"id": "3", "zone": nil, "never": nil},
+			},
+		},
+		{
+			name: "computed fields of aggregates",
+			sql:  "SELECT COUNT(*) * 10 AS n, MAX(age) - MIN(age) AS spread FROM users",
+			expected: []map[string]any{
+				{"n": int64(30), "spread": int64(10)},
+			},
+		},
+		{
+			name: "literals are constant fields",
+			sql:  "SELECT 1 AS one, id, 'x', null FROM users WHERE id != '2'",
+			expected: []map[string]any{
+				{"one": int64(1), "id": "1", "'x'": "x", "NULL": nil},
+				{"one": int64(1), "id": "3", "'x'": "x", "NULL": nil},
+			},
+		},
+		{
+			name: "CASE expressions label rows",
+			sql:  "SELECT id, CASE WHEN age >= 30 THEN 'senior' ELSE 'junior' END AS level, CASE address.zip WHEN '10001' THEN 'NY' END AS city FROM users ORDER BY level DESC, id",
+			expected: []map[string]any{
+				{"id": "1", "level": "senior", "city": "NY"},
+				{"id": "3", "level": "senior", "city": nil},
+				{"id": "2", "level": "junior", "city": nil},
+			},
+		},
+		{
+			name: "failed conversions are nil and unknown",
+			sql:  "SELECT id, CAST(name AS INT) AS n, age::FLOAT AS years FROM users WHERE name::INT IS NULL AND (name::INT > 0 OR id::INT > 1) ORDER BY n, id DESC",
+			expected: []map[string]any{
+				{"id": "3", "n": nil, "years": 35.0},
+				{"id": "2", "n": nil, "years": 25.0},
+			},
+		},
+		{
+			name: "CASE expressions in WHERE",
+			sql:  "SELECT id FROM users WHERE CASE WHEN address.zip IS NULL THEN 0 ELSE 1 END = 0",
+			expected: []map[string]any{
+				{"id": "3"},
+			},
+		},
+		{
+			name:        "non SELECT query fails",
+			sql:         "DELETE FROM users WHERE id = '1'",
+			expectedErr: "only SELECT queries can be filtered",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := FilterProjected(tt.sql, data)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
 			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+// keywordPrefixedNames are column names starting or ending with a keyword, which must not be split, and
+// keywords that are names outside the statements they belong to
+var keywordPrefixedNames = []string{
+	"INDEX", "Inventory", "settings", "asset", "fromage", "into_date", "values2", "selection", "updated_at",
+	"deleted", "created", "tables", "whereabouts", "ascii", "description", "android", "order_id", "notes",
+	"nothing", "likes", "income", "bytes", "groups", "having_fun", "limits", "offsets", "insertion", "Setup",
+	"cases", "whenever", "thence", "elsewhere", "endpoint", "casting", "cast", "when", "then", "else", "end",
+}
+
+func TestKeywordPrefixedNames(t *testing.T) {
+	for _, name := range keywordPrefixedNames {
+		t.Run(name, func(t *testing.T) {
+			sql := fmt.Sprintf("SELECT %[1]s, COUNT(%[1]s) FROM 'a' WHERE %[1]s = '1' AND NOT %[1]s IN ('2') GROUP BY %[1]s ORDER BY %[1]s ASC", name)
+			q, err := Parse(sql)
+			require.NoError(t, err, sql)
+			require.Equal(t, []string{name, "COUNT(" + name + ")"}, q.Fields)
+			require.Equal(t, &AndExpr{
+				Left:  Condition{Operand1: name, Operand1IsField: true, Operator: Eq, Operand2: "1", Operand2Value: NewString("1")},
+				Right: &NotExpr{Expr: Condition{Operand1: name, Operand1IsField: true, Operator: In, InValues: []Value{NewString("2")}}},
+			}, q.Where)
+			require.Equal(t, []string{name}, q.GroupBy)
+			require.Equal(t, []OrderBy{{Field: name}}, q.OrderBy)
+
+			sql = fmt.Sprintf("INSERT INTO 'a' (%[1]s) VALUES ('1')", name)
+			q, err = Parse(sql)
+			require.NoError(t, err, sql)
+			require.Equal(t, []string{name}, q.Fields)
+
+			sql = fmt.Sprintf("UPDATE 'a' SET %[1]s = '1' WHERE %[1]s NOT LIKE '%%x'", name)
+			q, err = Parse(sql)
+			require.NoError(t, err, sql)
+			require.Equal(t, []Assignment{{Field: name, Value: &Literal{Value: NewString("1")}}}, q.Assignments)
+			require.Equal(t, []Condition{{Operand1: name, Operand1IsField: true, Operator: NotLike, Operand2: "%x", Operand2Value: NewString("%x")}}, q.Conditions)
+
+			sql = fmt.Sprintf("CREATE TABLE a (%[1]s string)", name)
+			q, err = Parse(sql)
+			require.NoError(t, err, sql)
+			require.Equal(t, []Column{{Name: name, Type: "string"}}, q.Columns)
+			require.Equal(t, map[string]string{name: "string"}, q.CreateFields())
+		})
+	}
+}
+
+func TestCreateFields(t *testing.T) {
+	q, err := Parse("CREATE TABLE a (b INT, c VARCHAR(255), d DECIMAL(10, 2))")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"b": "INT", "c": "VARCHAR(255)", "d": "DECIMAL(10, 2)"}, q.CreateFields())
+}
+
+func TestMultiWordKeywordsWithWhitespace(t *testing.T) {
+	tests := []struct {
+		sql      string
+		expected Query
+	}{
+		{
+			sql:      "INSERT\n\tINTO 'a' (b) VALUES ('1')",
+			expected: Query{Type: Insert, TableName: "a", Fields: []string{"b"}, Inserts: [][]ValueExpr{{&Literal{Value: NewString("1")}}}},
+		},
+		{
+			sql: "delete   /* all */ from 'a' WHERE b not\r\nlike '%x' AND c NOT -- list\n IN ('1')",
+			expected: withWhere(Query{Type: Delete, TableName: "a", Conditions: []Condition{
+				{Operand1: "b", Operand1IsField: true, Operator: NotLike, Operand2: "%x", Operand2Value: NewString("%x")},
+				{Operand1: "c", Operand1IsField: true, Operator: NotIn, InValues: []Value{NewString("1")}},
+			}}),
+		},
+		{
+			sql:      "CREATE    TABLE a (b string)",
+			expected: Query{Type: Create, TableName: "a", Columns: []Column{{Name: "b", Type: "string"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			q, err := Parse(tt.sql)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, q)
 		})
 	}
 }
+
+func TestBacktickQuotedIdentifiers(t *testing.T) {
+	q, err := Parse("UPDATE `a` SET `b c` = '1' WHERE `d.e` = '2' AND f = `g``h`")
+	require.NoError(t, err)
+	require.Equal(t, withWhere(Query{
+		Type:        Update,
+		TableName:   "a",
+		Assignments: []Assignment{{Field: "b c", Value: &Literal{Value: NewString("1")}}},
+		Conditions: []Condition{
+			{Operand1: "d.e", Operand1IsField: true, Operand1Quoted: true, Operator: Eq, Operand2: "2", Operand2Value: NewString("2")},
+			{Operand1: "f", Operand1IsField: true, Operator: Eq, Operand2: "g`h", Operand2IsField: true, Operand2Quoted: true},
+		},
+		QuotedNames: map[string]bool{"a": true, "b c": true},
+	}), q)
+	require.Equal(t, `UPDATE "a" SET "b c" = '1' WHERE "d.e" = '2' AND f = "g`+"`"+`h"`, q.String())
+}
@@ -0,0 +1,309 @@
+package sqlparser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Table is a mutable in-memory table. Rows are keyed by a unique identifier, like the data taken by
+// FilterRecursive, and hold column names as keys. The zero value is an empty table ready to use.
+// A Table is safe for concurrent use.
+type Table struct {
+	// KeyField is the field whose value keys inserted rows. When it is empty, or when an inserted
+	// row doesn't set it, the row is keyed by the smallest unused positive integer
+	KeyField string
+
+	mu   sync.RWMutex
+	rows map[string]map[string]any
+}
+
+// NewTable returns a table holding rows. The table takes ownership of the map.
+func NewTable(rows map[string]map[string]any) *Table {
+	if rows == nil {
+		rows = map[string]map[string]any{}
+	}
+	return &Table{rows: rows}
+}
+
+// Rows returns a snapshot of the rows of the table. Rows are never modified in place by the
+// table, so the snapshot is safe to read while the table changes.
+func (t *Table) Rows() map[string]map[string]any {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	rows := make(map[string]map[string]any, len(t.rows))
+	for key, row := range t.rows {
+		rows[key] = row
+	}
+	return rows
+}
+
+// Select executes a SELECT query against the table, see FilterProjected
+func (t *Table) Select(q Query) ([]map[string]any, error) {
+	if q.Type != Select {
+		return nil, fmt.Errorf("only SELECT queries can be selected")
+	}
+	program, err := CompileQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return program.project(t.rows), nil
+}
+
+// Exec executes an INSERT, UPDATE, DELETE or TRUNCATE query against the table and returns the number
+// of affected rows. The table name of the query is not checked.
+func (t *Table) Exec(q Query) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.rows == nil {
+		t.rows = map[string]map[string]any{}
+	}
+	switch q.Type {
+	case Insert:
+		return t.insert(q)
+	case Update:
+		return t.update(q)
+	case Delete:
+		return t.delete(q), nil
+	case Truncate:
+		return t.truncate(), nil
+	default:
+		return 0, fmt.Errorf("only INSERT, UPDATE, DELETE and TRUNCATE queries can be executed")
+	}
+}
+
+// insert adds the rows of an INSERT query. It adds none of them if any key is already used.
+func (t *Table) insert(q Query) (int, error) {
+	inserts, err := insertValues(q)
+	if err != nil {
+		return 0, err
+	}
+	keys := make([]string, len(inserts))
+	used := map[string]bool{}
+	for i, values := range inserts {
+		if len(values) != len(q.Fields) {
+			return 0, fmt.Errorf("value count doesn't match field count")
+		}
+		for j, field := range q.Fields {
+			if t.KeyField != "" && field == t.KeyField {
+				keys[i] = values[j].text()
+			}
+		}
+		if keys[i] == "" {
+			continue
+		}
+		if _, exists := t.rows[keys[i]]; exists || used[keys[i]] {
+			return 0, fmt.Errorf("duplicate key %s", keys[i])
+		}
+		used[keys[i]] = true
+	}
+
+	next := 1
+	for i, values := range inserts {
+		if keys[i] == "" {
+			for ; t.rows[strconv.Itoa(next)] != nil || used[strconv.Itoa(next)]; next++ {
+			}
+			keys[i] = strconv.Itoa(next)
+			used[keys[i]] = true
+		}
+		row := map[string]any{}
+		for j, field := range q.Fields {
+			row = setFieldValue(row, strings.Split(field, "."), values[j].Any())
+		}
+		t.rows[keys[i]] = row
+	}
+	return len(inserts), nil
+}
+
+// insertValues computes the rows of values of an INSERT query. They are computed each time the query
+// runs, so that NOW() is the time of the insert.
+func insertValues(q Query) ([][]Value, error) {
+	inserts := make([][]Value, len(q.Inserts))
+	for i, exprs := range q.Inserts {
+		inserts[i] = make([]Value, len(exprs))
+		for j, expr := range exprs {
+			value, err := compileValueExpr(expr, q.Strict)(nil)
+			if err != nil {
+				return nil, fmt.Errorf("at INSERT INTO: %w", err)
+			}
+			inserts[i][j] = value
+		}
+	}
+	return inserts, nil
+}
+
+// update applies the SET clause of an UPDATE query to the rows matching its WHERE clause. Values are
+// computed from the rows before the update, and no row changes if any value can't be computed. Values
+// that are just a field are copied unchanged.
+// Updated rows are replaced by modified copies, so rows handed out earlier don't change.
+func (t *Table) update(q Query) (int, error) {
+	where := compileExpr(q.where(), q.Strict)
+	assignments := compileAssignments(q)
+	updated := map[string]map[string]any{}
+	for key, row := range t.rows {
+		if !where.matches(row) {
+			continue
+		}
+		values := make([]any, len(q.Assignments))
+		for i, assignment := range q.Assignments {
+			// A field is copied as it is, such as a nested map or an int
+			if ref, ok := assignment.Value.(*FieldRef); ok {
+				values[i], _ = getFieldValue(row, ref.Name)
+				continue
+			}
+			value, err := assignments[i](row)
+			if err != nil {
+				return 0, fmt.Errorf("at SET %s: %w", assignment.Field, err)
+			}
+			values[i] = value.Any()
+		}
+		for i, assignment := range q.Assignments {
+			row = setFieldValue(row, strings.Split(assignment.Field, "."), values[i])
+		}
+		updated[key] = row
+	}
+	for key, row := range updated {
+		t.rows[key] = row
+	}
+	return len(updated), nil
+}
+
+// compileAssignments compiles the values of the SET clause of an UPDATE query, in order
+func compileAssignments(q Query) []valueFunc {
+	values := make([]valueFunc, len(q.Assignments))
+	for i, assignment := range q.Assignments {
+		values[i] = compileValueExpr(assignment.Value, q.Strict)
+	}
+	return values
+}
+
+// delete removes the rows matching the WHERE clause of a DELETE query
+func (t *Table) delete(q Query) int {
+	where := compileExpr(q.where(), q.Strict)
+	count := 0
+	for key, row := range t.rows {
+		if where.matches(row) {
+			delete(t.rows, key)
+			count++
+		}
+	}
+	return count
+}
+
+// truncate removes every row of the table
+func (t *Table) truncate() int {
+	count := len(t.rows)
+	t.rows = map[string]map[string]any{}
+	return count
+}
+
+// setFieldValue returns a copy of data with the dot separated field path set to value, copying
+// the nested maps along the path and creating missing ones
+func setFieldValue(data map[string]any, fieldParts []string, value any) map[string]any {
+	copied := make(map[string]any, len(data)+1)
+	for k, v := range data {
+		copied[k] = v
+	}
+	if len(fieldParts) == 1 {
+		copied[fieldParts[0]] = value
+		return copied
+	}
+	nested, _ := copied[fieldParts[0]].(map[string]any)
+	copied[fieldParts[0]] = setFieldValue(nested, fieldParts[1:], value)
+	return copied
+}
+
+// Database is a set of named in-memory tables that SQL strings are executed against.
+// The zero value is an empty database ready to use. A Database is safe for concurrent use.
+type Database struct {
+	// Strict runs queries with Query.Strict set
+	Strict bool
+
+	mu     sync.RWMutex
+	tables map[string]*Table
+}
+
+// NewDatabase returns an empty database
+func NewDatabase() *Database {
+	return &Database{tables: map[string]*Table{}}
+}
+
+// AddTable adds a table to the database, replacing any table with the same name
+func (db *Database) AddTable(name string, table *Table) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.tables == nil {
+		db.tables = map[string]*Table{}
+	}
+	db.tables[name] = table
+}
+
+// Table returns the table with the given name
+func (db *Database) Table(name string) (*Table, bool) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	table, ok := db.tables[name]
+	return table, ok
+}
+
+// Query executes a SELECT query against the database, see FilterProjected
+func (db *Database) Query(sql string) ([]map[string]any, error) {
+	q, err := Parse(sql)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SQL: %w", err)
+	}
+	q.Strict = db.Strict
+	table, ok := db.Table(q.TableName)
+	if !ok {
+		return nil, fmt.Errorf("table %s does not exist", q.TableName)
+	}
+	return table.Select(q)
+}
+
+// Exec executes a CREATE TABLE, DROP TABLE, TRUNCATE, INSERT, UPDATE or DELETE query against the
+// database and returns the number of affected rows, which TRUNCATE counts like DELETE. ALTER TABLE
+// queries can't be executed.
+func (db *Database) Exec(sql string) (int, error) {
+	q, err := Parse(sql)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse SQL: %w", err)
+	}
+	q.Strict = db.Strict
+	switch q.Type {
+	case Alter:
+		return 0, fmt.Errorf("ALTER TABLE queries can't be executed against a database")
+	case Drop:
+		db.mu.Lock()
+		defer db.mu.Unlock()
+		if _, exists := db.tables[q.TableName]; !exists {
+			if q.IfExists {
+				return 0, nil
+			}
+			return 0, fmt.Errorf("table %s does not exist", q.TableName)
+		}
+		delete(db.tables, q.TableName)
+		return 0, nil
+	case Create:
+		db.mu.Lock()
+		defer db.mu.Unlock()
+		if _, exists := db.tables[q.TableName]; exists {
+			if q.IfNotExists {
+				return 0, nil
+			}
+			return 0, fmt.Errorf("table %s already exists", q.TableName)
+		}
+		if db.tables == nil {
+			db.tables = map[string]*Table{}
+		}
+		db.tables[q.TableName] = NewTable(nil)
+		return 0, nil
+	}
+	table, ok := db.Table(q.TableName)
+	if !ok {
+		return 0, fmt.Errorf("table %s does not exist", q.TableName)
+	}
+	return table.Exec(q)
+}
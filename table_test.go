@@ -0,0 +1,205 @@
+package sqlparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableExec(t *testing.T) {
+	db := NewDatabase()
+	devices := NewTable(map[string]map[string]any{
+		"a": {"id": "a", "state": "on", "temp": "20", "meta": map[string]any{"room": "1"}},
+		"b": {"id": "b", "state": "off", "temp": "30"},
+	})
+	devices.KeyField = "id"
+	db.AddTable("devices", devices)
+
+	before := devices.Rows()
+
+	steps := []struct {
+		sql         string
+		affected    int
+		expectedErr string
+	}{
+		{sql: "INSERT INTO devices (id, state, temp) VALUES ('c', 'on', '25'), ('d', 'off', '35')", affected: 2},
+		{sql: "INSERT INTO devices (id, state) VALUES ('e', 'on'), ('a', 'on')", expectedErr: "duplicate key a"},
+		{sql: "UPDATE devices SET state = 'on', meta.room = '2' WHERE temp > '28'", affected: 2},
+		{sql: "UPDATE devices SET state = 'off' WHERE id = 'z'", affected: 0},
+		{sql: "DELETE FROM devices WHERE state = 'on' AND temp < '22' OR id = 'c'", affected: 2},
+		{sql: "DELETE FROM missing WHERE id = 'a'", expectedErr: "table missing does not exist"},
+		{sql: "SELECT * FROM devices", expectedErr: "only INSERT, UPDATE, DELETE and TRUNCATE queries can be executed"},
+	}
+	for _, step := range steps {
+		affected, err := db.Exec(step.sql)
+		if step.expectedErr != "" {
+			require.EqualError(t, err, step.expectedErr, step.sql)
+			continue
+		}
+		require.NoError(t, err, step.sql)
+		require.Equal(t, step.affected, affected, step.sql)
+	}
+
+	require.Equal(t, map[string]map[string]any{
+		"b": {"id": "b", "state": "on", "temp": "30", "meta": map[string]any{"room": "2"}},
+		"d": {"id": "d", "state": "on", "temp": "35", "meta": map[string]any{"room": "2"}},
+	}, devices.Rows())
+
+	// Rows handed out before the changes are untouched
+	require.Equal(t, map[string]any{"id": "b", "state": "off", "temp": "30"}, before["b"])
+
+	rows, err := db.Query("SELECT id FROM devices WHERE meta.room = '2' ORDER BY temp DESC")
+	require.NoError(t, err)
+	require.Equal(t, []map[string]any{{"id": "d"}, {"id": "b"}}, rows)
+}
+
+func TestTableUpdateExpressions(t *testing.T) {
+	db := NewDatabase()
+	counters := NewTable(map[string]map[string]any{
+		"a": {"count": 1, "step": "2", "name": "a"},
+		"b": {"count": 10, "step": 0.5, "name": "b"},
+		"c": {"count": nil, "step": 1, "name": "c"},
+	})
+	db.AddTable("counters", counters)
+
+	// All values are computed from the row before the update
+	affected, err := db.Exec("UPDATE counters SET count = count + step * 2, step = count, name = name WHERE name != 'x'")
+	require.NoError(t, err)
+	require.Equal(t, 3, affected)
+	require.Equal(t, map[string]map[string]any{
+		"a": {"count": int64(5), "step": 1, "name": "a"},
+		"b": {"count": 11.0, "step": 10, "name": "b"},
+		"c": {"count": nil, "step": nil, "name": "c"},
+	}, counters.Rows())
+
+	// No row changes when a value of any row can't be computed
+	_, err = db.Exec("UPDATE counters SET count = 1 / (step - 1) WHERE name != 'x'")
+	require.EqualError(t, err, "at SET count: division by zero")
+	require.Equal(t, int64(5), counters.Rows()["a"]["count"])
+
+	// Strict arithmetic doesn't take numeric strings, which must be converted
+	db.Strict = true
+	_, err = db.Exec("UPDATE counters SET step = '3' WHERE name = 'a'")
+	require.NoError(t, err)
+	_, err = db.Exec("UPDATE counters SET step = step * 2 WHERE name = 'a'")
+	require.EqualError(t, err, "at SET step: cannot apply * to '3'")
+	_, err = db.Exec("UPDATE counters SET step = CAST(step AS INT) * 2 WHERE name = 'a'")
+	require.NoError(t, err)
+	require.Equal(t, int64(6), counters.Rows()["a"]["step"])
+	_, err = db.Exec("UPDATE counters SET name = count::TEXT WHERE name = 'a'")
+	require.NoError(t, err)
+	require.Equal(t, "5", counters.Rows()["a"]["name"])
+}
+
+func TestTableUpdateCopiesFields(t *testing.T) {
+	table := NewTable(map[string]map[string]any{
+		"a": {"meta": map[string]any{"x": 5}, "n": 3, "small": int32(7)},
+	})
+	affected, err := table.Exec(Query{Type: Update, TableName: "t", Assignments: []Assignment{
+		{Field: "copy", Value: &FieldRef{Name: "meta"}},
+		{Field: "m", Value: &FieldRef{Name: "n"}},
+		{Field: "s", Value: &FieldRef{Name: "small"}},
+		{Field: "x", Value: &FieldRef{Name: "meta.x"}},
+	}})
+	require.NoError(t, err)
+	require.Equal(t, 1, affected)
+	require.Equal(t, map[string]any{
+		"meta": map[string]any{"x": 5}, "n": 3, "small": int32(7),
+		"copy": map[string]any{"x": 5}, "m": 3, "s": int32(7), "x": 5,
+	}, table.Rows()["a"])
+
+	db := NewDatabase()
+	db.AddTable("t", table)
+	_, err = db.Exec("UPDATE t SET n = small, meta = copy WHERE n = 3")
+	require.NoError(t, err)
+	require.Equal(t, int32(7), table.Rows()["a"]["n"])
+	require.Equal(t, map[string]any{"x": 5}, table.Rows()["a"]["meta"])
+}
+
+func TestTableInsertGeneratesKeys(t *testing.T) {
+	db := &Database{}
+	_, err := db.Exec("CREATE TABLE logs (level string, message string)")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE logs (level string)")
+	require.EqualError(t, err, "table logs already exists")
+	_, err = db.Exec("CREATE TABLE IF NOT EXISTS logs (level string)")
+	require.NoError(t, err)
+
+	affected, err := db.Exec("INSERT INTO logs (level, message) VALUES ('INFO', 'started'), ('ERROR', 'failed')")
+	require.NoError(t, err)
+	require.Equal(t, 2, affected)
+
+	logs, ok := db.Table("logs")
+	require.True(t, ok)
+	require.Equal(t, map[string]map[string]any{
+		"1": {"level": "INFO", "message": "started"},
+		"2": {"level": "ERROR", "message": "failed"},
+	}, logs.Rows())
+
+	_, err = db.Exec("DELETE FROM logs WHERE level = 'INFO'")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO logs (level, message) VALUES ('WARN', 'slow')")
+	require.NoError(t, err)
+	require.Equal(t, map[string]map[string]any{
+		"1": {"level": "WARN", "message": "slow"},
+		"2": {"level": "ERROR", "message": "failed"},
+	}, logs.Rows())
+}
+
+func TestDatabaseDropAndTruncate(t *testing.T) {
+	db := NewDatabase()
+	db.AddTable("logs", NewTable(map[string]map[string]any{"1": {"level": "INFO"}, "2": {"level": "WARN"}}))
+
+	affected, err := db.Exec("TRUNCATE TABLE logs")
+	require.NoError(t, err)
+	require.Equal(t, 2, affected)
+	logs, ok := db.Table("logs")
+	require.True(t, ok)
+	require.Empty(t, logs.Rows())
+
+	_, err = db.Exec("ALTER TABLE logs ADD COLUMN message TEXT")
+	require.EqualError(t, err, "ALTER TABLE queries can't be executed against a database")
+
+	_, err = db.Exec("DROP TABLE logs")
+	require.NoError(t, err)
+	_, ok = db.Table("logs")
+	require.False(t, ok)
+	_, err = db.Exec("DROP TABLE logs")
+	require.EqualError(t, err, "table logs does not exist")
+	_, err = db.Exec("DROP TABLE IF EXISTS logs")
+	require.NoError(t, err)
+	_, err = db.Exec("TRUNCATE TABLE logs")
+	require.EqualError(t, err, "table logs does not exist")
+}
+
+func TestTableInsertExpressions(t *testing.T) {
+	next := int64(0)
+	require.NoError(t, RegisterFunction("next_id", 0, func([]Value) (Value, error) {
+		next++
+		return NewInt(next), nil
+	}))
+	q, err := Parse("INSERT INTO ids (id, label) VALUES (NEXT_ID() * 10, 'id ' || NEXT_ID())")
+	require.NoError(t, err)
+	require.Equal(t, "INSERT INTO ids (id, label) VALUES (NEXT_ID() * 10, 'id ' || NEXT_ID())", q.String())
+
+	// Values are computed each time the query runs
+	ids := NewTable(nil)
+	ids.KeyField = "id"
+	for i := 0; i < 2; i++ {
+		_, err = ids.Exec(q)
+		require.NoError(t, err)
+	}
+	require.Equal(t, map[string]map[string]any{
+		"10": {"id": int64(10), "label": "id 2"},
+		"30": {"id": int64(30), "label": "id 4"},
+	}, ids.Rows())
+
+	// No row is inserted when a value can't be computed
+	db := NewDatabase()
+	db.AddTable("ids", ids)
+	_, err = db.Exec("INSERT INTO ids (id) VALUES (5), (1 / 0)")
+	require.EqualError(t, err, "at INSERT INTO: division by zero")
+	_, err = db.Exec("INSERT INTO ids (id) VALUES (CAST('x' AS INT))")
+	require.EqualError(t, err, "at INSERT INTO: cannot cast 'x' to INT")
+	require.Len(t, ids.Rows(), 2)
+}
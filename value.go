@@ -0,0 +1,268 @@
+package sqlparser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ValueKind is the type of a literal value
+type ValueKind int
+
+const (
+	// NullValue is NULL, the kind of the zero Value
+	NullValue ValueKind = iota
+	// StringValue is a quoted string such as 'a'
+	StringValue
+	// IntValue is an integer such as 10
+	IntValue
+	// FloatValue is a number with a fraction or an exponent such as 1.5
+	FloatValue
+	// BoolValue is TRUE or FALSE
+	BoolValue
+)
+
+// ValueKindString is a string slice with the names of all value kinds in order
+var ValueKindString = []string{
+	"NullValue",
+	"StringValue",
+	"IntValue",
+	"FloatValue",
+	"BoolValue",
+}
+
+func (k ValueKind) String() string {
+	if k < 0 || int(k) >= len(ValueKindString) {
+		return fmt.Sprintf("ValueKind(%d)", int(k))
+	}
+	return ValueKindString[k]
+}
+
+// Value is a typed literal of a query. Only the field matching Kind is set.
+type Value struct {
+	Kind  ValueKind
+	Str   string
+	Int   int64
+	Float float64
+	Bool  bool
+}
+
+// NewString returns a string Value
+func NewString(s string) Value {
+	return Value{Kind: StringValue, Str: s}
+}
+
+// NewInt returns an integer Value
+func NewInt(i int64) Value {
+	return Value{Kind: IntValue, Int: i}
+}
+
+// NewFloat returns a floating point Value
+func NewFloat(f float64) Value {
+	return Value{Kind: FloatValue, Float: f}
+}
+
+// NewBool returns a boolean Value
+func NewBool(b bool) Value {
+	return Value{Kind: BoolValue, Bool: b}
+}
+
+// String returns the value as a SQL literal, quoting strings
+func (v Value) String() string {
+	switch v.Kind {
+	case StringValue:
+		return "'" + v.Str + "'"
+	case BoolValue:
+		return strings.ToUpper(v.text())
+	case NullValue:
+		return "NULL"
+	case FloatValue:
+		// Keep a fraction so that the literal reads back as a float
+		if text := v.text(); !strings.ContainsAny(text, ".eIN") {
+			return text + ".0"
+		}
+		return v.text()
+	default:
+		return v.text()
+	}
+}
+
+// Any returns the value as a string, int64, float64, bool or nil
+func (v Value) Any() any {
+	switch v.Kind {
+	case StringValue:
+		return v.Str
+	case IntValue:
+		return v.Int
+	case FloatValue:
+		return v.Float
+	case BoolValue:
+		return v.Bool
+	default:
+		return nil
+	}
+}
+
+// valueOf returns a row value as a Value. Strings, booleans, integers and floats keep their kind, nil
+// is NULL and anything else is formatted as a string.
+func valueOf(value any) Value {
+	switch v := value.(type) {
+	case nil:
+		return Value{}
+	case string:
+		return NewString(v)
+	case bool:
+		return NewBool(v)
+	case int:
+		return NewInt(int64(v))
+	case int32:
+		return NewInt(int64(v))
+	case int64:
+		return NewInt(v)
+	case float32:
+		return NewFloat(float64(v))
+	case float64:
+		return NewFloat(v)
+	default:
+		return NewString(fmt.Sprintf("%v", v))
+	}
+}
+
+// number returns an integer or float value as a float64
+func (v Value) number() float64 {
+	if v.Kind == IntValue {
+		return float64(v.Int)
+	}
+	return v.Float
+}
+
+// text returns the value as plain text, as stored in a CSV file. NULL is empty.
+func (v Value) text() string {
+	switch v.Kind {
+	case StringValue:
+		return v.Str
+	case IntValue:
+		return strconv.FormatInt(v.Int, 10)
+	case FloatValue:
+		return strconv.FormatFloat(v.Float, 'g', -1, 64)
+	case BoolValue:
+		return strconv.FormatBool(v.Bool)
+	default:
+		return ""
+	}
+}
+
+// parseNumber returns the Value of an unquoted number, an integer unless it has a fraction or exponent
+// or overflows
+func parseNumber(s string) (Value, bool) {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return NewInt(i), true
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return Value{}, false
+	}
+	return NewFloat(f), true
+}
+
+// compareValue compares a row value with a literal according to the kind of the literal: numbers
+// compare numerically, also with numeric strings such as CSV fields, strings compare as strings,
+// except with a number when the string is numeric, and booleans compare with booleans and boolean
+// strings. It returns -1, 0 or 1, and false when the values can't be compared, such as anything with
+// NULL.
+func compareValue(value any, literal Value) (int, bool) {
+	return newLiteral(literal).compare(value)
+}
+
+// literal is a Value prepared for comparisons with row values: numbers, and strings that are numeric,
+// are converted to float64 once
+type literal struct {
+	Value
+	number  float64
+	numeric bool
+}
+
+func newLiteral(value Value) literal {
+	l := literal{Value: value}
+	switch value.Kind {
+	case IntValue:
+		l.number, l.numeric = float64(value.Int), true
+	case FloatValue:
+		l.number, l.numeric = value.Float, true
+	case StringValue:
+		number, err := strconv.ParseFloat(value.Str, 64)
+		l.number, l.numeric = number, err == nil
+	}
+	return l
+}
+
+// compare compares a row value with the literal, see compareValue
+func (l literal) compare(value any) (int, bool) {
+	if value == nil {
+		return 0, false
+	}
+	switch l.Kind {
+	case IntValue, FloatValue:
+		number, ok := toFloat64(value)
+		if !ok {
+			return 0, false
+		}
+		return compareFloat64(number, l.number), true
+	case StringValue:
+		if s, ok := value.(string); ok {
+			return strings.Compare(s, l.Str), true
+		}
+		if number, ok := toFloat64(value); ok {
+			if !l.numeric {
+				return 0, false
+			}
+			return compareFloat64(number, l.number), true
+		}
+		return strings.Compare(fmt.Sprintf("%v", value), l.Str), true
+	case BoolValue:
+		b, ok := value.(bool)
+		if s, isString := value.(string); isString {
+			var err error
+			b, err = strconv.ParseBool(s)
+			ok = err == nil
+		}
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case b == l.Bool:
+			return 0, true
+		case l.Bool:
+			return -1, true
+		default:
+			return 1, true
+		}
+	default:
+		return 0, false
+	}
+}
+
+// compareFieldValues compares two row values: numerically when both are numbers or numeric strings,
+// and as text otherwise. It returns false when either is nil.
+func compareFieldValues(value1, value2 any) (int, bool) {
+	if value1 == nil || value2 == nil {
+		return 0, false
+	}
+	if number1, ok := toFloat64(value1); ok {
+		if number2, ok := toFloat64(value2); ok {
+			return compareFloat64(number1, number2), true
+		}
+	}
+	return strings.Compare(fmt.Sprintf("%v", value1), fmt.Sprintf("%v", value2)), true
+}
+
+func compareFloat64(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
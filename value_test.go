@@ -0,0 +1,50 @@
+package sqlparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    any
+		literal  Value
+		expected int
+		ok       bool
+	}{
+		{name: "int with int", value: 10, literal: NewInt(9), expected: 1, ok: true},
+		{name: "float with int", value: 9.5, literal: NewInt(10), expected: -1, ok: true},
+		{name: "numeric string with int", value: "100", literal: NewInt(30), expected: 1, ok: true},
+		{name: "numeric string with float", value: "1.5", literal: NewFloat(1.5), expected: 0, ok: true},
+		{name: "string with int", value: "abc", literal: NewInt(1), ok: false},
+		{name: "bool with int", value: true, literal: NewInt(1), ok: false},
+		{name: "numeric strings with string", value: "100", literal: NewString("30"), expected: -1, ok: true},
+		{name: "int with numeric string", value: 100, literal: NewString("30"), expected: 1, ok: true},
+		{name: "int with string", value: 100, literal: NewString("abc"), ok: false},
+		{name: "bool with string", value: true, literal: NewString("true"), expected: 0, ok: true},
+		{name: "bool with bool", value: false, literal: NewBool(true), expected: -1, ok: true},
+		{name: "boolean string with bool", value: "TRUE", literal: NewBool(true), expected: 0, ok: true},
+		{name: "string with bool", value: "yes", literal: NewBool(true), ok: false},
+		{name: "nil with string", value: nil, literal: NewString(""), ok: false},
+		{name: "string with NULL", value: "", literal: Value{}, ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmp, ok := compareValue(tt.value, tt.literal)
+			require.Equal(t, tt.ok, ok)
+			require.Equal(t, tt.expected, cmp)
+		})
+	}
+}
+
+func TestValueString(t *testing.T) {
+	require.Equal(t, "'a'", NewString("a").String())
+	require.Equal(t, "-3", NewInt(-3).String())
+	require.Equal(t, "1e+21", NewFloat(1e21).String())
+	require.Equal(t, "2.5", NewFloat(2.5).String())
+	require.Equal(t, "-150.0", NewFloat(-150).String())
+	require.Equal(t, "FALSE", NewBool(false).String())
+	require.Equal(t, "NULL", Value{}.String())
+}